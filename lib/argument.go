@@ -2,14 +2,21 @@ package lib
 
 import (
 	"os"
+	"strconv"
 	"strings"
 )
 
 // ArgumentLoader defines a loader that loads configuration from command line arguments
 type ArgumentLoader struct {
-	LowerCase bool
-	Prefix    string
-	Separator string
+	LowerCase     bool
+	Prefix        string
+	Separator     string
+	Inference     Inference           // How values are converted into typed values, defaults to InferAggressive
+	TypeHints     map[string]TypeHint // Types to parse the values of specific keys into, overriding the inference policy
+	IndexedSlices bool                // Whether keys whose parts are all indexes, e.g. --servers:0:host, build slices
+	ListSeparator string              // Splits values containing it into slices, e.g. "," for --tags=a,b,c. Disabled when empty
+	Positional    []string            // The positional arguments found by the last parse
+	sources       sourceRecorder
 }
 
 // NewArgumentLoader creates a new argument loader
@@ -25,49 +32,156 @@ func (loader *ArgumentLoader) Load() (map[string]interface{}, error) {
 	return loader.ParseArguments(os.Args[1:])
 }
 
-// ParseArguments parses command line arguments into valid types
+// ParseArguments parses command line arguments into valid types. Options can be supplied as `--key=value` or `--key value`,
+// bare `--key` flags are true and `--no-key` flags are false. Options hinted as booleans never take the next argument as
+// their value. Repeated options are collected into a slice. Anything that isn't an option, as well as everything after a
+// `--` terminator, is collected into Positional
 func (loader *ArgumentLoader) ParseArguments(args []string) (map[string]interface{}, error) {
 	config := map[string]interface{}{}
+	repeated := map[string]bool{}
+	loader.sources = sourceRecorder{}
+	loader.Positional = []string{}
 
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 
-		// If the argument doesn't start with `-`, ignore it
-		if !strings.HasPrefix(arg, "-") {
-			continue
+		// Everything after the terminator is positional
+		if arg == "--" {
+			loader.Positional = append(loader.Positional, args[i+1:]...)
+			break
 		}
 
-		// Split the argument up
-		parts := strings.Split(arg, "=")
-
-		// If the option doesn't have exactly 2 parts, ignore it
-		if len(parts) != 2 {
+		// If the argument isn't an option, it's positional
+		if !isOption(arg) {
+			loader.Positional = append(loader.Positional, arg)
 			continue
 		}
 
-		// Read in the key and value
-		key := strings.TrimLeft(parts[0], "-")
-		value := parts[1]
+		// Read in the key and value. The value either follows an `=` (and may contain more of them), is the next argument
+		// unless the key is hinted as a boolean, or is implied by the flag being present
+		source := arg
+		key := strings.TrimLeft(arg, "-")
+		var value interface{} = true
+		var rawValue *string
+
+		parts := strings.SplitN(key, "=", 2)
+		if len(parts) == 2 {
+			key = parts[0]
+			rawValue = &parts[1]
+		} else if strings.HasPrefix(key, "no-") && strings.HasPrefix(strings.TrimPrefix(key, "no-"), loader.Prefix) {
+			key = strings.TrimPrefix(key, "no-")
+			value = false
+		} else if i+1 < len(args) && args[i+1] != "--" && !isOption(args[i+1]) && !loader.isBooleanFlag(key) {
+			i++
+			source = arg + " " + args[i]
+			rawValue = &args[i]
+		}
 
-		// If we have a prefix and the key doesn't match it, ignore this line
+		// If we have a prefix and the key doesn't match it, ignore this option
 		if len(loader.Prefix) > 0 && !strings.HasPrefix(key, loader.Prefix) {
 			continue
 		}
 
-		// Trim the prefix off the argument name
+		// Trim the prefix off the argument name, ignoring options that don't have a name
 		trimmedKey := strings.TrimPrefix(key, loader.Prefix)
+		if len(trimmedKey) == 0 {
+			continue
+		}
 
 		// Separate it on the separator if required
-		separatedKey := []string{trimmedKey}
-		if len(loader.Separator) > 0 {
-			separatedKey = strings.Split(trimmedKey, loader.Separator)
+		separatedKey := loader.separateKey(trimmedKey)
+
+		// Parse the value if there is one
+		if rawValue != nil {
+			parsedValue, err := parseValue(loader.Inference, loader.TypeHints, loader.ListSeparator, separatedKey, *rawValue)
+			if err != nil {
+				return config, err
+			}
+			value = parsedValue
 		}
 
-		// Parse the value and add it to the final config map
-		_, err := Set(config, separatedKey, ParseString(value))
+		// Add the value to the final config map, collecting repeated options into a slice
+		joinedKey := JoinKey(separatedKey)
+		_, err := Set(config, separatedKey, value)
 		if err != nil {
-			return config, err
+			err = appendRepeated(config, separatedKey, value, repeated[joinedKey], err)
+			if err != nil {
+				return config, err
+			}
+			repeated[joinedKey] = true
+			continue
 		}
+		loader.sources.record(separatedKey, source)
 	}
 
+	if loader.IndexedSlices {
+		return buildIndexedSlices(config, loader.sources)
+	}
 	return config, nil
 }
+
+// separateKey splits the key of an option on the separator, if there is one
+func (loader *ArgumentLoader) separateKey(key string) []string {
+	if len(loader.Separator) == 0 {
+		return []string{key}
+	}
+	return strings.Split(key, loader.Separator)
+}
+
+// isBooleanFlag checks if the key of an option is hinted as a boolean
+func (loader *ArgumentLoader) isBooleanFlag(key string) bool {
+	hint, hinted := loader.TypeHints[JoinKey(loader.separateKey(strings.TrimPrefix(key, loader.Prefix)))]
+	return hinted && hint == BooleanHint
+}
+
+// isOption checks if an argument is an option rather than a value. Negative numbers are values
+func isOption(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || arg == "-" {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+// appendRepeated handles an option that was supplied more than once by collecting its values into a slice. The supplied
+// error from setting the value is returned if it wasn't caused by a repeated option
+func appendRepeated(config map[string]interface{}, keys []string, value interface{}, alreadyRepeated bool, setError error) error {
+	conflictError, castConflictError := setError.(*ConflictError)
+	if !castConflictError || conflictError.Key != JoinKey(keys) {
+		return setError
+	}
+
+	// Nested options can't be repeated, they conflict with each other
+	_, existingIsMap := conflictError.Existing.(map[string]interface{})
+	if existingIsMap {
+		return setError
+	}
+
+	// Options that conflict with an element of a slice, e.g. --x:0 after --x=[1,2], aren't repeats either
+	parent := config
+	if len(keys) > 1 {
+		parentValue, _ := Get(config, keys[:len(keys)-1])
+		parentMap, castParentMap := parentValue.(map[string]interface{})
+		if !castParentMap {
+			return setError
+		}
+		parent = parentMap
+	}
+
+	key := keys[len(keys)-1]
+	if alreadyRepeated {
+		parent[key] = append(parent[key].([]interface{}), value)
+	} else {
+		parent[key] = []interface{}{parent[key], value}
+	}
+	return nil
+}
+
+// Describe describes which command line argument supplied the supplied keys
+func (loader *ArgumentLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "arguments",
+		Source: loader.sources.lookup(keys),
+	}
+}
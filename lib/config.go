@@ -1,56 +1,323 @@
 package lib
 
-import "github.com/mitchellh/mapstructure"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
 
 // Loader defines a generic loader interface
 type Loader interface {
 	Load() (map[string]interface{}, error)
 }
 
-// Config defines the overall configuration structure
+// LoaderPolicy defines how a failing loader is handled when it is used
+type LoaderPolicy int
+
+const (
+	// Required loaders cause Use to fail whenever they return an error
+	Required LoaderPolicy = iota
+
+	// Optional loaders are ignored when their source doesn't exist, any other error still causes Use to fail
+	Optional
+)
+
+// source defines a loader in the configuration loading chain along with the data it last loaded
+type source struct {
+	name     string
+	priority int
+	loader   Loader
+	policy   LoaderPolicy
+	data     map[string]interface{}
+	origins  map[string]Origin // Where each leaf of data came from, described when it was loaded
+}
+
+// load runs the loader, handling failures according to the source's policy, and returns a copy of the source holding the
+// loaded data. Origins are described straight away, since loaders forget where their values came from when loading again
+func (s *source) load() (*source, error) {
+	loadedMap, err := s.loader.Load()
+	if err != nil {
+
+		// Optional loaders are allowed to be missing their source, even when the error wraps the missing file's error
+		if s.policy != Optional || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		loadedMap = map[string]interface{}{}
+	}
+
+	// Keep our own copy so that changes to the loaded map (e.g. a map loader's defaults) don't leak in either direction
+	loaded := *s
+	loaded.data = DeepCopy(loadedMap)
+	loaded.origins = Origins(s.loader, loaded.data)
+	return &loaded, nil
+}
+
+// describe describes where the supplied raw keys came from using the origins recorded when the source was loaded. Maps are
+// described by the sources of the values under them
+func (s *source) describe(keys []string) Origin {
+	joinedKey := JoinKey(keys)
+	origin, found := s.origins[joinedKey]
+	if found {
+		return Origin{Loader: origin.Loader, Source: origin.Source}
+	}
+
+	originKeys := []string{}
+	for originKey := range s.origins {
+		if strings.HasPrefix(originKey, joinedKey+":") {
+			originKeys = append(originKeys, originKey)
+		}
+	}
+	sort.Strings(originKeys)
+
+	if len(originKeys) == 0 {
+		return Origin{}
+	}
+
+	sources := []string{}
+	seen := map[string]bool{}
+	for _, originKey := range originKeys {
+		source := s.origins[originKey].Source
+		if len(source) > 0 && !seen[source] {
+			sources = append(sources, source)
+			seen[source] = true
+		}
+	}
+	return Origin{Loader: s.origins[originKeys[0]].Loader, Source: strings.Join(sources, ", ")}
+}
+
+// Config defines the overall configuration structure. It is safe for concurrent use: the merged map is never modified once
+// built, every change builds a new one and swaps it in while holding the lock. Loaders run before the lock is taken, so
+// reads aren't held up by slow sources and loaders are free to read the configuration. Values are copied on their way in
+// and out, so neither loaders nor callers ever share maps or slices with the configuration
 type Config struct {
-	Map map[string]interface{}
+	data      map[string]interface{}
+	origins   map[string][]Origin
+	sources   []*source
+	overrides []override
+	conflicts []MergeConflict
+	strict    bool
+	mutex     sync.RWMutex
+	loading   sync.Mutex // Held while loaders run and their data is swapped in, so that loaders never run concurrently
+
+	normalization KeyNormalization
+	rawKeys       map[string][]string // The keys of every normalized key as they were loaded or set, the first loader wins
+	delimiter     string
+
+	subscriptions []*subscription
+	watchers      []*Watcher // The watchers started by Watch, which follow the files of the loading chain
+
+	parent *Config  // The configuration a write-through sub-config reads from and writes to, nil otherwise
+	prefix []string // The keys of a write-through sub-config's values within its parent
 }
 
 // NewConfig creates a new configuration structure
 func NewConfig() *Config {
 	return &Config{
-		Map: map[string]interface{}{},
+		data:      map[string]interface{}{},
+		origins:   map[string][]Origin{},
+		delimiter: KeyDelimiter,
+	}
+}
+
+// SetDelimiter sets the delimiter between the keys of the nested keys passed to the configuration, which defaults to ":".
+// A backslash escapes the delimiter inside a key
+func (config *Config) SetDelimiter(delimiter string) {
+	if config.parent != nil {
+		config.parent.SetDelimiter(delimiter)
+		return
+	}
+	if len(delimiter) == 0 {
+		delimiter = KeyDelimiter
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+	config.delimiter = delimiter
+}
+
+// keyDelimiter returns the delimiter that keys are split on and reported with
+func (config *Config) keyDelimiter() string {
+	root, _ := config.root()
+	root.mutex.RLock()
+	defer root.mutex.RUnlock()
+	return root.delimiter
+}
+
+// Snapshot returns a copy of the loaded configuration map
+func (config *Config) Snapshot() map[string]interface{} {
+	return DeepCopy(config.snapshot())
+}
+
+// snapshot returns the current loaded configuration map, which must not be modified
+func (config *Config) snapshot() map[string]interface{} {
+	root, prefix := config.root()
+	root.mutex.RLock()
+	data := root.data
+	root.mutex.RUnlock()
+
+	if len(prefix) == 0 {
+		return data
+	}
+
+	// Write-through sub-configs see an empty map when their sub-map is gone
+	value, err := Get(data, prefix)
+	mapValue, castMapValue := value.(map[string]interface{})
+	if err != nil || !castMapValue {
+		return map[string]interface{}{}
+	}
+	return mapValue
+}
+
+// Use adds a required loader to the configuration loading chain. Loaders added with Use have a priority of 0, so they win
+// in the order they were added
+func (config *Config) Use(loader Loader) error {
+	return config.UseWithPolicy(loader, Required)
+}
+
+// UseWithPolicy adds a loader to the configuration loading chain, handling load failures according to the supplied policy.
+// Optional loaders are remembered even when their source doesn't exist, so that it is picked up by a later Reload
+func (config *Config) UseWithPolicy(loader Loader, policy LoaderPolicy) error {
+	if config.parent != nil {
+		return config.parent.UseWithPolicy(&prefixLoader{loader: loader, keys: config.prefix}, policy)
+	}
+
+	return config.addSource(&source{loader: loader, policy: policy})
+}
+
+// Reload re-runs every loader in the chain and rebuilds the configuration from scratch. If any loader fails, the
+// previously loaded configuration is left in place and the error is returned
+func (config *Config) Reload() error {
+	if config.parent != nil {
+		return config.parent.Reload()
+	}
+
+	config.loading.Lock()
+	defer config.loading.Unlock()
+
+	config.mutex.RLock()
+	previousSources := config.sources
+	config.mutex.RUnlock()
+
+	// Load everything into new sources without holding the lock, so a failure leaves the old configuration intact
+	reloadedSources := make(map[*source]*source, len(previousSources))
+	for _, s := range previousSources {
+		reloaded, err := s.load()
+		if err != nil {
+			return withDelimiter(err, config.keyDelimiter())
+		}
+		reloadedSources[s] = reloaded
+	}
+
+	return config.update(func() error {
+
+		// Layers removed while loading stay removed
+		sources := make([]*source, 0, len(config.sources))
+		for _, s := range config.sources {
+			if reloaded, found := reloadedSources[s]; found {
+				s = reloaded
+			}
+			sources = append(sources, s)
+		}
+		return config.rebuild(sources, config.overrides)
+	})
+}
+
+// update runs the supplied function while holding the configuration lock, then notifies subscribers of any values it changed.
+// Subscribers are notified after the lock is released so that they are free to read the configuration
+func (config *Config) update(fn func() error) error {
+	config.mutex.Lock()
+	previousMap := config.data
+	err := withDelimiter(fn(), config.delimiter)
+	notifications := config.changes(previousMap, config.data)
+	config.mutex.Unlock()
+
+	for _, notify := range notifications {
+		notify()
 	}
+	return err
 }
 
-// Use adds a loader to the configuration loading chain
-func (config *Config) Use(loader Loader) {
+// rebuild merges the data of the supplied sources and overrides into a brand new map and makes them the configuration's
+// state. In strict mode, nothing changes if the sources have conflicting types
+func (config *Config) rebuild(sources []*source, overrides []override) error {
+	m := map[string]interface{}{}
+	origins := map[string][]Origin{}
+	conflicts := []MergeConflict{}
+	rawKeys := map[string][]string{}
+
+	// Record where each value came from, then merge it with the values loaded so far, noting any conflicting types
+	normalizedSources := make([]normalizedSource, len(sources))
+	for i, s := range sources {
+		normalizedSources[i] = config.normalization.normalizeSource(s)
+		for key, raw := range normalizedSources[i].rawKeys {
+			if _, found := rawKeys[key]; !found {
+				rawKeys[key] = raw
+			}
+		}
+		for key, origin := range s.origins {
+			normalizedKey := JoinKey(config.normalization.NormalizeKeys(SplitKey(key)))
+			origins[normalizedKey] = append(origins[normalizedKey], origin)
+		}
+		MergeFunc(m, normalizedSources[i].data, func(keys []string, keptValue interface{}, ignoredValue interface{}) {
+			conflicts = append(conflicts, newMergeConflict(normalizedSources[:i], normalizedSources[i], keys, keptValue, ignoredValue))
+		})
+	}
+
+	// Keys are merged in no particular order, so order the conflicts to make them stable
+	sort.SliceStable(conflicts, func(i int, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	if config.strict && len(conflicts) > 0 {
+		return &MergeConflictError{Conflicts: conflicts}
+	}
+
+	// Values set or deleted in memory take priority over every loader
+	for _, o := range overrides {
+		config.normalizeOverride(o, rawKeys).apply(m, origins)
+	}
 
-	// Load in the config map from this loader
-	loadedMap, err := loader.Load()
+	config.sources, config.overrides = sources, overrides
+	config.data, config.origins, config.conflicts, config.rawKeys = m, origins, conflicts, rawKeys
+	return nil
+}
+
+// MustUse adds a required loader to the configuration loading chain, panicking if it fails to load
+func (config *Config) MustUse(loader Loader) {
+	err := config.Use(loader)
 	if err != nil {
 		panic(err)
 	}
-
-	// Merge it with our existing values
-	Merge(config.Map, loadedMap)
 }
 
-// ToStructure maps the loaded configuration to a structure
+// ToStructure maps the loaded configuration to a structure. The structure receives its own copy of any maps and slices.
+// Once decoded, the structure is validated against the `validate` and `pattern` tags of its fields and the registered
+// validators, and a ValidationError listing every violation is returned if any of them fail
 func (config *Config) ToStructure(structure interface{}) error {
-	return mapstructure.Decode(config.Map, structure)
-}
+	root, prefix := config.root()
+	root.mutex.RLock()
+	normalization, rawKeys := root.normalization, root.rawKeys
+	root.mutex.RUnlock()
 
-// Get gets a key from the loaded configuration
-func (config *Config) Get(key string) (interface{}, error) {
-	return Get(config.Map, SplitKey(key))
+	data := normalization.rekey(config.Snapshot(), reflect.TypeOf(structure), prefix, rawKeys)
+	err := mapstructure.Decode(data, structure)
+	if err != nil {
+		return err
+	}
+	return validate(structure, data, config.keyDelimiter())
 }
 
-// GetSubConfig gets a loaded submap as a configuration structure
-func (config *Config) GetSubConfig(key string) (*Config, error) {
-	value, err := config.GetMap(key)
+// Get gets a key from the loaded configuration. Maps and slices are copied, so modifying them doesn't affect the configuration
+func (config *Config) Get(key string) (interface{}, error) {
+	value, err := Get(config.snapshot(), config.keys(key))
 	if err != nil {
-		return nil, err
+		return nil, withDelimiter(err, config.keyDelimiter())
 	}
-	return &Config{
-		Map: value,
-	}, nil
+	return deepCopyValue(value), nil
 }
 
 // GetMap gets a map from the loaded configuration
@@ -59,7 +326,7 @@ func (config *Config) GetMap(key string) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastMap(value)
+	return cast(key, value, CastMap)
 }
 
 // GetSlice gets a slice from the loaded configuration
@@ -68,7 +335,7 @@ func (config *Config) GetSlice(key string) ([]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastSlice(value)
+	return cast(key, value, CastSlice)
 }
 
 // GetStringSlice gets a string slice from the loaded configuration
@@ -77,7 +344,7 @@ func (config *Config) GetStringSlice(key string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastStringSlice(value)
+	return cast(key, value, CastStringSlice)
 }
 
 // GetString gets a string from the loaded configuration
@@ -86,7 +353,7 @@ func (config *Config) GetString(key string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return CastString(value)
+	return cast(key, value, CastString)
 }
 
 // GetIntegerSlice gets a integer slice from the loaded configuration
@@ -95,7 +362,7 @@ func (config *Config) GetIntegerSlice(key string) ([]int, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastIntegerSlice(value)
+	return cast(key, value, CastIntegerSlice)
 }
 
 // GetInteger gets a integer from the loaded configuration
@@ -104,7 +371,7 @@ func (config *Config) GetInteger(key string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	return CastInteger(value)
+	return cast(key, value, CastInteger)
 }
 
 // GetBooleanSlice gets a boolean slice from the loaded configuration
@@ -113,7 +380,7 @@ func (config *Config) GetBooleanSlice(key string) ([]bool, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastBooleanSlice(value)
+	return cast(key, value, CastBooleanSlice)
 }
 
 // GetBoolean gets a boolean from the loaded configuration
@@ -122,7 +389,7 @@ func (config *Config) GetBoolean(key string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return CastBoolean(value)
+	return cast(key, value, CastBoolean)
 }
 
 // GetFloatSlice gets a float slice from the loaded configuration
@@ -131,7 +398,7 @@ func (config *Config) GetFloatSlice(key string) ([]float64, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CastFloatSlice(value)
+	return cast(key, value, CastFloatSlice)
 }
 
 // GetFloat gets a float from the loaded configuration
@@ -140,11 +407,79 @@ func (config *Config) GetFloat(key string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return CastFloat(value)
+	return cast(key, value, CastFloat)
 }
 
-// Set sets a value in the loaded configuration
-func (config *Config) Set(key string, value interface{}) error {
-	_, err := Set(config.Map, SplitKey(key), value)
-	return err
+// GetDurationSlice gets a duration slice from the loaded configuration
+func (config *Config) GetDurationSlice(key string) ([]time.Duration, error) {
+	value, err := config.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return cast(key, value, CastDurationSlice)
+}
+
+// GetDuration gets a duration from the loaded configuration
+func (config *Config) GetDuration(key string) (time.Duration, error) {
+	value, err := config.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return cast(key, value, CastDuration)
+}
+
+// GetTimeSlice gets a time slice from the loaded configuration
+func (config *Config) GetTimeSlice(key string) ([]time.Time, error) {
+	value, err := config.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return cast(key, value, CastTimeSlice)
+}
+
+// GetTime gets a time from the loaded configuration
+func (config *Config) GetTime(key string) (time.Time, error) {
+	value, err := config.Get(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cast(key, value, CastTime)
+}
+
+// Explain describes which loader supplied the value of a key and which lower priority values it shadowed
+func (config *Config) Explain(key string) (*Explanation, error) {
+	if config.parent != nil {
+		explanation, err := config.parent.Explain(config.fullKey(key))
+		if err != nil {
+			return nil, err
+		}
+		delimiter := config.keyDelimiter()
+		explanation.Key = strings.TrimPrefix(explanation.Key, JoinKeyWith(config.prefix, delimiter)+delimiter)
+		return explanation, nil
+	}
+
+	_, err := config.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only leaf values have their origins recorded. Values inside slices are attributed to the origin of the slice
+	keys := config.keys(key)
+	delimiter := config.keyDelimiter()
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+	for i := len(keys); i > 0; i-- {
+		origins := config.origins[JoinKey(keys[:i])]
+		if len(origins) == 0 {
+			continue
+		}
+
+		return &Explanation{
+			Key:      JoinKeyWith(keys, delimiter),
+			Origin:   origins[0],
+			Shadowed: origins[1:],
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no origin recorded for key '%s'", key)
 }
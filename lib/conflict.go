@@ -0,0 +1,55 @@
+package lib
+
+// MergeConflict describes a key that is a map in one loader but not in another. Maps and other values can't be merged, so
+// the value from the higher priority loader is kept and the other one is ignored
+type MergeConflict struct {
+	Key     string
+	Kept    Origin // Where the value that was kept came from
+	Ignored Origin // Where the value that was ignored came from
+}
+
+// newMergeConflict describes a conflict between the value of a source and the value merged from the supplied higher priority
+// sources
+func newMergeConflict(higherSources []normalizedSource, s normalizedSource, keys []string, keptValue interface{}, ignoredValue interface{}) MergeConflict {
+	conflict := MergeConflict{
+		Key:     JoinKey(keys),
+		Ignored: s.describe(keys),
+	}
+	conflict.Ignored.Value = ignoredValue
+
+	// The kept value came from the highest priority source that has the key
+	for _, higherSource := range higherSources {
+		_, err := Get(higherSource.data, keys)
+		if err == nil {
+			conflict.Kept = higherSource.describe(keys)
+			break
+		}
+	}
+	conflict.Kept.Value = keptValue
+
+	return conflict
+}
+
+// Conflicts lists the keys that are a map in one loader but not in another, found when the configuration was last merged
+func (config *Config) Conflicts() []MergeConflict {
+	if config.parent != nil {
+		return config.parent.Conflicts()
+	}
+
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+	return withConflictDelimiter(config.conflicts, config.delimiter)
+}
+
+// SetStrict enables or disables strict mode. In strict mode, adding, removing or reloading loaders fails with a
+// MergeConflictError rather than ignoring values when a key is a map in one loader but not in another
+func (config *Config) SetStrict(strict bool) {
+	if config.parent != nil {
+		config.parent.SetStrict(strict)
+		return
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+	config.strict = strict
+}
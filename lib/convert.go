@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// Converter defines a function that converts a loaded value to a specific type
+type Converter func(value interface{}) (interface{}, error)
+
+var converters = map[reflect.Type]Converter{}
+var convertersMutex sync.RWMutex
+
+func init() {
+	RegisterConverter(CastMap)
+	RegisterConverter(CastSlice)
+	RegisterConverter(CastStringSlice)
+	RegisterConverter(CastString)
+	RegisterConverter(CastIntegerSlice)
+	RegisterConverter(CastInteger)
+	RegisterConverter(CastInteger64)
+	RegisterConverter(CastUnsignedInteger)
+	RegisterConverter(CastBooleanSlice)
+	RegisterConverter(CastBoolean)
+	RegisterConverter(CastFloatSlice)
+	RegisterConverter(CastFloat)
+	RegisterConverter(CastDurationSlice)
+	RegisterConverter(CastDuration)
+	RegisterConverter(CastTimeSlice)
+	RegisterConverter(CastTime)
+	RegisterConverter(CastURL)
+	RegisterConverter(func(value interface{}) (url.URL, error) {
+		parsedURL, err := CastURL(value)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *parsedURL, nil
+	})
+	RegisterConverter(CastStringMap)
+}
+
+// RegisterConverter registers a function that converts loaded values to T, replacing any converter already registered for T
+func RegisterConverter[T any](convert func(value interface{}) (T, error)) {
+	convertersMutex.Lock()
+	defer convertersMutex.Unlock()
+
+	converters[typeOf[T]()] = func(value interface{}) (interface{}, error) {
+		return convert(value)
+	}
+}
+
+// Convert converts a value to T using the converter registered for T. Values that already are a T are returned as is
+func Convert[T any](value interface{}) (T, error) {
+	var zero T
+
+	typedValue, cast := value.(T)
+	if cast {
+		return typedValue, nil
+	}
+
+	convertersMutex.RLock()
+	converter, found := converters[typeOf[T]()]
+	convertersMutex.RUnlock()
+	if !found {
+		return zero, fmt.Errorf("no converter registered for type %v", typeOf[T]())
+	}
+
+	converted, err := converter(value)
+	if err != nil {
+		return zero, err
+	}
+	return converted.(T), nil
+}
+
+// GetAs gets a key from the loaded configuration and converts it to T
+func GetAs[T any](config *Config, key string) (T, error) {
+	value, err := config.Get(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return cast(key, value, Convert[T])
+}
+
+// GetOr gets a key from the loaded configuration and converts it to T, returning the supplied default if the key doesn't
+// exist or can't be converted
+func GetOr[T any](config *Config, key string, defaultValue T) T {
+	value, err := GetAs[T](config, key)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// typeOf returns the reflected type of T, which also works for interface types
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,313 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// DefaultsLoader defines a loader that loads the defaults of a structure, from the `default` tags of its fields or from the
+// values of an already populated instance
+type DefaultsLoader struct {
+	Structure interface{} // The structure, or a pointer to it, read on every load. A nil pointer reads only the tags
+	sources   sourceRecorder
+}
+
+// NewDefaultsLoader creates a new defaults loader
+func NewDefaultsLoader(structure interface{}) *DefaultsLoader {
+	return &DefaultsLoader{
+		Structure: structure,
+	}
+}
+
+// Load reads the defaults of the structure into a configuration map, using the same keys as ToStructure. Fields that are
+// set in the instance take their value from it, otherwise the `default` tag is parsed into the type of the field. Slices
+// of scalars can be written as comma separated lists, other slices, maps and structures as JSON
+func (loader *DefaultsLoader) Load() (map[string]interface{}, error) {
+	value := reflect.ValueOf(loader.Structure)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.Zero(value.Type().Elem())
+			continue
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("defaults must be read from a structure, not %T", loader.Structure)
+	}
+
+	loader.sources = sourceRecorder{}
+	config := map[string]interface{}{}
+	err := loader.readFields(value, []string{}, value.Type().Name(), config, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readFields reads the defaults of the fields of a structure into the supplied map. Squashed structures share the map of
+// their parent, and the types being read are tracked so that recursive types aren't followed forever
+func (loader *DefaultsLoader) readFields(value reflect.Value, parentKeys []string, parentPath string, m map[string]interface{},
+	visiting map[reflect.Type]bool) error {
+	visiting[value.Type()] = true
+	defer delete(visiting, value.Type())
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, squash, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		keys := append(parentKeys[:len(parentKeys):len(parentKeys)], name)
+		path := field.Name
+		if len(parentPath) > 0 {
+			path = parentPath + "." + field.Name
+		}
+
+		// Go into nested structures that don't have a default of their own. Nil pointers are left nil so that optional
+		// sections stay optional
+		tag, tagged := field.Tag.Lookup("default")
+		nested, isNested := nestedStructure(value.Field(i), visiting)
+		if isNested && (squash || !tagged) {
+			if !squash && value.Field(i).Kind() == reflect.Ptr && value.Field(i).IsNil() {
+				continue
+			}
+			if squash {
+				err := loader.readFields(nested, parentKeys, path, m, visiting)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			nestedMap := map[string]interface{}{}
+			err := loader.readFields(nested, keys, path, nestedMap, visiting)
+			if err != nil {
+				return err
+			}
+			if len(nestedMap) > 0 {
+				m[name] = nestedMap
+			}
+			continue
+		}
+
+		// Values set in the instance take priority over the tag
+		var fieldDefault interface{}
+		if !value.Field(i).IsZero() {
+			fieldDefault = defaultValue(value.Field(i))
+		} else if tagged {
+			parsed, err := parseDefault(tag, field.Type)
+			if err != nil {
+				return withKey(JoinKey(keys), err)
+			}
+			fieldDefault = parsed
+		}
+		if fieldDefault == nil {
+			continue
+		}
+
+		m[name] = fieldDefault
+		loader.sources.record(keys, path)
+	}
+	return nil
+}
+
+// fieldName works out the key of a structure field the same way mapstructure does, and whether it's squashed into its
+// parent or skipped altogether
+func fieldName(field reflect.StructField) (name string, squash bool, skip bool) {
+	tagParts := strings.Split(field.Tag.Get("mapstructure"), ",")
+	squash = hasTagOption(tagParts[1:], "squash")
+	if tagParts[0] == "-" || (len(field.PkgPath) > 0 && !(field.Anonymous && squash)) {
+		return "", false, true
+	}
+
+	name = tagParts[0]
+	if len(name) == 0 {
+		name = field.Name
+	}
+	return name, squash, false
+}
+
+// nestedStructure finds the structure a field holds, following pointers. Nil pointers give the zero value of the structure
+func nestedStructure(value reflect.Value, visiting map[reflect.Type]bool) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.Zero(value.Type().Elem())
+			continue
+		}
+		value = value.Elem()
+	}
+	isStructure := value.Kind() == reflect.Struct && value.Type() != timeType && !visiting[value.Type()]
+	return value, isStructure
+}
+
+// parseDefault parses the `default` tag of a field into the type of the field
+func parseDefault(tag string, t reflect.Type) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType:
+		duration, err := time.ParseDuration(tag)
+		if err != nil {
+			return nil, newTypeError("duration", tag)
+		}
+		return duration, nil
+	case timeType:
+		parsedTime, err := time.Parse(time.RFC3339, tag)
+		if err != nil {
+			return nil, newTypeError("time", tag)
+		}
+		return parsedTime, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return tag, nil
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(tag)
+		if err != nil {
+			return nil, newTypeError("boolean", tag)
+		}
+		return boolValue, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intValue, err := strconv.ParseInt(tag, 10, t.Bits())
+		if err != nil {
+			return nil, newTypeError("integer", tag)
+		}
+		return defaultValue(reflect.ValueOf(intValue)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintValue, err := strconv.ParseUint(tag, 10, t.Bits())
+		if err != nil {
+			return nil, newTypeError("unsigned integer", tag)
+		}
+		return defaultValue(reflect.ValueOf(uintValue)), nil
+	case reflect.Float32, reflect.Float64:
+		floatValue, err := strconv.ParseFloat(tag, t.Bits())
+		if err != nil {
+			return nil, newTypeError("float", tag)
+		}
+		return floatValue, nil
+	case reflect.Slice:
+		if !strings.HasPrefix(strings.TrimSpace(tag), "[") {
+			return parseDefaultList(tag, t)
+		}
+	}
+
+	parsed := reflect.New(t)
+	err := json.Unmarshal([]byte(tag), parsed.Interface())
+	if err != nil {
+		return nil, newTypeError(t.String(), tag)
+	}
+	return defaultValue(parsed.Elem()), nil
+}
+
+// parseDefaultList parses a comma separated `default` tag into a slice, parsing each element into the element type
+func parseDefaultList(tag string, t reflect.Type) (interface{}, error) {
+	elements := strings.Split(tag, ",")
+	list := make([]interface{}, len(elements))
+	for i, element := range elements {
+		parsed, err := parseDefault(strings.TrimSpace(element), t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		list[i] = parsed
+	}
+	return list, nil
+}
+
+// defaultValue converts a value read from a structure into the types the other loaders produce, e.g. every integer becomes
+// an int and structures become maps. Nil pointers, slices and maps give nil
+func defaultValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return defaultValue(value.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			return time.Duration(value.Int())
+		}
+		return narrowInteger(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Uint() > math.MaxInt64 {
+			return value.Uint()
+		}
+		return defaultValue(reflect.ValueOf(int64(value.Uint())))
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.String:
+		return value.String()
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		slice := make([]interface{}, value.Len())
+		for i := range slice {
+			slice[i] = defaultValue(value.Index(i))
+		}
+		return slice
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		m := make(map[string]interface{}, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			m[iterator.Key().String()] = defaultValue(iterator.Value())
+		}
+		return m
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return value.Interface()
+		}
+		m := map[string]interface{}{}
+		structureValue(value, m)
+		return m
+	default:
+		return nil
+	}
+}
+
+// structureValue converts every field of a structure into the supplied map, squashed structures share the map of their parent
+func structureValue(value reflect.Value, m map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		name, squash, skip := fieldName(value.Type().Field(i))
+		if skip {
+			continue
+		}
+
+		nested, isNested := nestedStructure(value.Field(i), map[reflect.Type]bool{})
+		if squash && isNested {
+			structureValue(nested, m)
+			continue
+		}
+
+		fieldValue := defaultValue(value.Field(i))
+		if fieldValue != nil {
+			m[name] = fieldValue
+		}
+	}
+}
+
+// Describe describes which field of the structure supplied the supplied keys
+func (loader *DefaultsLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "defaults",
+		Source: loader.sources.lookup(keys),
+	}
+}
@@ -7,9 +7,14 @@ import (
 
 // EnvironmentLoader defines a loader that loads configurations from environment variables
 type EnvironmentLoader struct {
-	LowerCase bool
-	Prefix    string
-	Separator string
+	LowerCase     bool
+	Prefix        string
+	Separator     string
+	Inference     Inference           // How values are converted into typed values, defaults to InferAggressive
+	TypeHints     map[string]TypeHint // Types to parse the values of specific keys into, overriding the inference policy
+	IndexedSlices bool                // Whether keys whose parts are all indexes, e.g. SERVERS__0__HOST, build slices
+	ListSeparator string              // Splits values containing it into slices, e.g. "," for TAGS=a,b,c. Disabled when empty
+	sources       sourceRecorder
 }
 
 // NewEnvironmentLoader creates a new environment loader
@@ -29,6 +34,7 @@ func (loader *EnvironmentLoader) Load() (map[string]interface{}, error) {
 // Parse environment parses environment variables into a configuration map
 func (loader *EnvironmentLoader) ParseEnvironment(environmentData []string) (map[string]interface{}, error) {
 	config := map[string]interface{}{}
+	loader.sources = sourceRecorder{}
 
 	for _, environmentLine := range environmentData {
 
@@ -65,13 +71,28 @@ func (loader *EnvironmentLoader) ParseEnvironment(environmentData []string) (map
 			separatedKeys = strings.Split(trimmedKey, loader.Separator)
 		}
 
-		// Set the nested value in the map
-		value := ParseString(keyValue[1])
-		_, err := Set(config, separatedKeys, value)
+		// Parse the value and set it in the map
+		value, err := parseValue(loader.Inference, loader.TypeHints, loader.ListSeparator, separatedKeys, keyValue[1])
 		if err != nil {
 			return config, err
 		}
+		_, err = Set(config, separatedKeys, value)
+		if err != nil {
+			return config, err
+		}
+		loader.sources.record(separatedKeys, keyValue[0])
 	}
 
+	if loader.IndexedSlices {
+		return buildIndexedSlices(config, loader.sources)
+	}
 	return config, nil
 }
+
+// Describe describes which environment variable supplied the supplied keys
+func (loader *EnvironmentLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "environment",
+		Source: loader.sources.lookup(keys),
+	}
+}
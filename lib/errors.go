@@ -0,0 +1,240 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound matches errors returned when a key doesn't exist
+	ErrNotFound = errors.New("key not found")
+
+	// ErrTypeMismatch matches errors returned when a value doesn't have the expected type
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrConflict matches errors returned when a value can't be set because another value is in the way
+	ErrConflict = errors.New("key conflict")
+
+	// ErrInvalid matches errors returned when a decoded structure breaks its validation rules
+	ErrInvalid = errors.New("validation failed")
+)
+
+// NotFoundError is returned when a key doesn't exist
+type NotFoundError struct {
+	Key string // The full key that was requested
+}
+
+// Error describes the missing key
+func (err *NotFoundError) Error() string {
+	return fmt.Sprintf("key '%s' was not found", err.Key)
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrNotFound)
+func (err *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ElementError describes the element of a slice that couldn't be cast
+type ElementError struct {
+	Index int
+	Value interface{}
+}
+
+// TypeError is returned when a value can't be cast to the expected type
+type TypeError struct {
+	Key      string        // The full key of the value, empty when the value wasn't read from a configuration
+	Expected string        // The type the value was expected to have
+	Actual   string        // The type the value actually had
+	Value    interface{}   // The value that couldn't be cast
+	Element  *ElementError // The slice element that couldn't be cast, nil when the value isn't a slice
+}
+
+// newTypeError creates a new type error for a value
+func newTypeError(expected string, value interface{}) *TypeError {
+	return &TypeError{
+		Expected: expected,
+		Actual:   fmt.Sprintf("%T", value),
+		Value:    value,
+	}
+}
+
+// newElementTypeError creates a new type error for a slice containing an element that couldn't be cast
+func newElementTypeError(expected string, value interface{}, index int, element interface{}) *TypeError {
+	typeError := newTypeError(expected, value)
+	typeError.Element = &ElementError{Index: index, Value: element}
+	return typeError
+}
+
+// Error describes the value that couldn't be cast, and the key it was read from if known
+func (err *TypeError) Error() string {
+	message := fmt.Sprintf("failed to cast value to %s: value has type %s", err.Expected, err.Actual)
+	if err.Element != nil {
+		message = fmt.Sprintf("failed to cast value to %s: element %d has type %T", err.Expected, err.Element.Index, err.Element.Value)
+	}
+
+	if len(err.Key) > 0 {
+		return fmt.Sprintf("key '%s': %s", err.Key, message)
+	}
+	return message
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrTypeMismatch)
+func (err *TypeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// ConflictError is returned when a value can't be set because the key, or one of its parents, is already present
+type ConflictError struct {
+	Key      string      // The full key that is already present
+	Existing interface{} // The value that is already present
+}
+
+// Error describes the key that is already present
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("configuration option '%s' already present", err.Key)
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrConflict)
+func (err *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// RangeError is returned when a value can't be set because its key indexes outside of a slice. Only the index one past the
+// end of a slice can be set, which appends to it
+type RangeError struct {
+	Key    string // The full key of the index
+	Length int    // The length of the slice
+}
+
+// Error describes the index and the slice it's outside of
+func (err *RangeError) Error() string {
+	return fmt.Sprintf("key '%s': index is out of range for a slice of length %d", err.Key, err.Length)
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrConflict), since the slice is in the way of the value
+func (err *RangeError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// MergeConflictError is returned in strict mode when a key is a map in one loader but not in another
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+// Error describes the first conflict, and how many others there are
+func (err *MergeConflictError) Error() string {
+	conflict := err.Conflicts[0]
+	message := fmt.Sprintf("key '%s': %T from %s conflicts with %T from %s", conflict.Key, conflict.Kept.Value, conflict.Kept,
+		conflict.Ignored.Value, conflict.Ignored)
+
+	if len(err.Conflicts) > 1 {
+		return fmt.Sprintf("%s (and %d more conflicts)", message, len(err.Conflicts)-1)
+	}
+	return message
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrConflict)
+func (err *MergeConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// IndexError is returned when the indexed keys of a slice don't run from 0 without gaps
+type IndexError struct {
+	Key     string // The full key of the slice
+	Missing int    // The first index that wasn't supplied
+}
+
+// Error describes the slice and the index that is missing
+func (err *IndexError) Error() string {
+	return fmt.Sprintf("key '%s': indexes must run from 0 without gaps, index %d is missing", err.Key, err.Missing)
+}
+
+// ValidationError is returned by ToStructure when values break the validation rules of the structure
+type ValidationError struct {
+	Violations []Violation // Every violation, in the order of the structure's fields
+}
+
+// Error lists every violation
+func (err *ValidationError) Error() string {
+	messages := make([]string, len(err.Violations))
+	for i, violation := range err.Violations {
+		messages[i] = violation.Message
+		if len(violation.Key) > 0 {
+			messages[i] = fmt.Sprintf("key '%s' %s", violation.Key, violation.Message)
+		}
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(messages, "; "))
+}
+
+// Is allows the error to be matched with errors.Is(err, ErrInvalid)
+func (err *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// withDelimiter rewrites the keys of errors from the map helpers, which always join keys with ":", using the supplied
+// delimiter so that they match the keys the configuration was asked for
+func withDelimiter(err error, delimiter string) error {
+	if err == nil || delimiter == KeyDelimiter {
+		return err
+	}
+
+	switch typedError := err.(type) {
+	case *NotFoundError:
+		delimitedError := *typedError
+		delimitedError.Key = rejoinKey(typedError.Key, delimiter)
+		return &delimitedError
+	case *TypeError:
+		delimitedError := *typedError
+		delimitedError.Key = rejoinKey(typedError.Key, delimiter)
+		return &delimitedError
+	case *ConflictError:
+		delimitedError := *typedError
+		delimitedError.Key = rejoinKey(typedError.Key, delimiter)
+		return &delimitedError
+	case *RangeError:
+		delimitedError := *typedError
+		delimitedError.Key = rejoinKey(typedError.Key, delimiter)
+		return &delimitedError
+	case *MergeConflictError:
+		return &MergeConflictError{Conflicts: withConflictDelimiter(typedError.Conflicts, delimiter)}
+	default:
+		return err
+	}
+}
+
+// withConflictDelimiter copies merge conflicts, joining their keys with the supplied delimiter
+func withConflictDelimiter(conflicts []MergeConflict, delimiter string) []MergeConflict {
+	delimited := make([]MergeConflict, len(conflicts))
+	for i, conflict := range conflicts {
+		delimited[i] = conflict
+		delimited[i].Key = rejoinKey(conflict.Key, delimiter)
+	}
+	return delimited
+}
+
+// rejoinKey joins a key that was joined with ":" with the supplied delimiter instead
+func rejoinKey(key string, delimiter string) string {
+	if len(key) == 0 || delimiter == KeyDelimiter {
+		return key
+	}
+	return JoinKeyWith(SplitKey(key), delimiter)
+}
+
+// withKey records the full key on type errors that don't know which key they were read from
+func withKey(key string, err error) error {
+	var typeError *TypeError
+	if !errors.As(err, &typeError) || len(typeError.Key) > 0 {
+		return err
+	}
+
+	keyedError := *typeError
+	keyedError.Key = key
+	return &keyedError
+}
+
+// cast casts a value read from a key, recording the key on any type error
+func cast[T any](key string, value interface{}, castValue func(interface{}) (T, error)) (T, error) {
+	typedValue, err := castValue(value)
+	return typedValue, withKey(key, err)
+}
@@ -0,0 +1,62 @@
+package lib
+
+import "strconv"
+
+// buildIndexedSlices turns the maps built by a loader whose keys are all indexes, e.g. from SERVERS__0__HOST and
+// SERVERS__1__HOST, into slices. Values that were recorded as a whole, like JSON objects, are left alone
+func buildIndexedSlices(m map[string]interface{}, sources sourceRecorder) (map[string]interface{}, error) {
+	for key, value := range m {
+		newValue, err := buildIndexedSlice([]string{key}, value, sources)
+		if err != nil {
+			return m, err
+		}
+		m[key] = newValue
+	}
+	return m, nil
+}
+
+// buildIndexedSlice turns the value at the supplied keys into a slice if it's a map of indexes, after doing the same for
+// its children
+func buildIndexedSlice(keys []string, value interface{}, sources sourceRecorder) (interface{}, error) {
+	m, castMap := value.(map[string]interface{})
+	if !castMap || len(m) == 0 || sources.recorded(keys) {
+		return value, nil
+	}
+
+	// Build the children first so that nested indexes, e.g. SERVERS__0__PORTS__0, become nested slices
+	indexed := true
+	for key, child := range m {
+		newChild, err := buildIndexedSlice(append(keys[:len(keys):len(keys)], key), child, sources)
+		if err != nil {
+			return value, err
+		}
+		m[key] = newChild
+		indexed = indexed && isIndex(key)
+	}
+	if !indexed {
+		return m, nil
+	}
+
+	slice := make([]interface{}, len(m))
+	for i := range slice {
+		element, found := m[strconv.Itoa(i)]
+		if !found {
+			return value, &IndexError{Key: JoinKey(keys), Missing: i}
+		}
+		slice[i] = element
+	}
+	return slice, nil
+}
+
+// isIndex checks if a key is written as a slice index, digits without a leading zero
+func isIndex(key string) bool {
+	if len(key) == 0 || (len(key) > 1 && key[0] == '0') {
+		return false
+	}
+	for _, character := range key {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Inference defines how the strings read by the environment and argument loaders are converted into typed values
+type Inference int
+
+const (
+	// InferAggressive parses integers, floats, booleans, JSON objects and arrays and durations, see ParseString
+	InferAggressive Inference = iota
+
+	// InferStrings keeps every value as a string
+	InferStrings
+
+	// InferJSON only parses values that are valid JSON literals, keeping everything else as a string
+	InferJSON
+)
+
+// Parse parses a string according to the inference policy. Values wrapped in matching single or double quotes are always
+// kept as strings, with the quotes removed
+func (inference Inference) Parse(value string) interface{} {
+	unquoted, quoted := Unquote(value)
+	if quoted {
+		return unquoted
+	}
+
+	switch inference {
+	case InferStrings:
+		return value
+	case InferJSON:
+		return ParseJSONLiteral(value)
+	default:
+		return ParseString(value)
+	}
+}
+
+// Unquote removes matching single or double quotes surrounding a value, reporting whether the value was quoted
+func Unquote(value string) (string, bool) {
+	if len(value) < 2 {
+		return value, false
+	}
+
+	first := value[0]
+	last := value[len(value)-1]
+	if first != last || (first != '"' && first != '\'') {
+		return value, false
+	}
+	return value[1 : len(value)-1], true
+}
+
+// ParseJSONLiteral parses a string that is a valid JSON literal, returning any other string as is. Integral numbers are
+// parsed into an int
+func ParseJSONLiteral(value string) interface{} {
+	var parsed interface{}
+	err := json.Unmarshal([]byte(value), &parsed)
+	if err != nil {
+		return value
+	}
+
+	// Keep the same number types as the aggressive parser
+	_, castNumber := parsed.(float64)
+	if castNumber {
+		intValue, err := strconv.ParseInt(value, 10, 0)
+		if err == nil {
+			return int(intValue)
+		}
+	}
+
+	return parsed
+}
+
+// TypeHint forces the value of a key read by the environment or argument loaders to be parsed as a specific type
+type TypeHint int
+
+const (
+	// StringHint keeps the value as a string
+	StringHint TypeHint = iota
+
+	// IntegerHint parses the value as an int
+	IntegerHint
+
+	// FloatHint parses the value as a float64
+	FloatHint
+
+	// BooleanHint parses the value as a bool
+	BooleanHint
+
+	// DurationHint parses the value as a time.Duration
+	DurationHint
+
+	// JSONHint parses the value as JSON
+	JSONHint
+)
+
+// Parse parses a string into the hinted type
+func (hint TypeHint) Parse(value string) (interface{}, error) {
+	switch hint {
+	case IntegerHint:
+		intValue, err := strconv.ParseInt(value, 10, 0)
+		if err != nil {
+			return nil, newTypeError("integer", value)
+		}
+		return int(intValue), nil
+	case FloatHint:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, newTypeError("float", value)
+		}
+		return floatValue, nil
+	case BooleanHint:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, newTypeError("boolean", value)
+		}
+		return boolValue, nil
+	case DurationHint:
+		durationValue, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, newTypeError("duration", value)
+		}
+		return durationValue, nil
+	case JSONHint:
+		var jsonValue interface{}
+		err := json.Unmarshal([]byte(value), &jsonValue)
+		if err != nil {
+			return nil, newTypeError("JSON", value)
+		}
+		return jsonValue, nil
+	default:
+		return value, nil
+	}
+}
+
+// parseValue parses the value of a key read by the environment or argument loaders, using the key's type hint if it has one.
+// When a list separator is supplied, values containing it are split into a slice and each element is parsed on its own
+func parseValue(inference Inference, hints map[string]TypeHint, listSeparator string, keys []string, value string) (interface{}, error) {
+	hint, hinted := hints[JoinKey(keys)]
+	if !isList(listSeparator, hint, hinted, value) {
+		return parseElement(inference, hint, hinted, keys, value)
+	}
+
+	elements := strings.Split(value, listSeparator)
+	list := make([]interface{}, len(elements))
+	for i, element := range elements {
+		parsed, err := parseElement(inference, hint, hinted, append(keys[:len(keys):len(keys)], strconv.Itoa(i)), strings.TrimSpace(element))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = parsed
+	}
+	return list, nil
+}
+
+// isList checks if a value should be split into a list. Quoted values, JSON arrays and objects and values hinted as JSON are
+// never split
+func isList(listSeparator string, hint TypeHint, hinted bool, value string) bool {
+	if len(listSeparator) == 0 || !strings.Contains(value, listSeparator) || (hinted && hint == JSONHint) {
+		return false
+	}
+
+	_, quoted := Unquote(value)
+	return !quoted && !strings.HasPrefix(value, "[") && !strings.HasPrefix(value, "{")
+}
+
+// parseElement parses a single value with its type hint if it has one, or the inference policy otherwise
+func parseElement(inference Inference, hint TypeHint, hinted bool, keys []string, value string) (interface{}, error) {
+	if !hinted {
+		return inference.Parse(value), nil
+	}
+
+	parsed, err := hint.Parse(value)
+	if err != nil {
+		return nil, withKey(JoinKey(keys), err)
+	}
+	return parsed, nil
+}
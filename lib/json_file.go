@@ -1,14 +1,22 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"strconv"
+	"strings"
 )
 
 // JSONFileLoader defines a loader that loads configurations from a JSON file
 type JSONFileLoader struct {
 	FilePath       string
 	ParseDurations bool
+	DurationKeys   []string // Limits duration parsing to these key paths (e.g. "http:timeout") when set
+	DurationSuffix string   // Limits duration parsing to keys ending with this suffix (e.g. "Timeout") when set
 }
 
 // NewJSONFileLoader creates a new JSON file loader
@@ -29,10 +37,24 @@ func (loader *JSONFileLoader) Load() (map[string]interface{}, error) {
 	return loader.ParseJSON(file)
 }
 
-// ParseJSON parses json into a configuration map
-func (loader *JSONFileLoader) ParseJSON(bytes []byte) (map[string]interface{}, error) {
+// ParseJSON parses json into a configuration map. Integral numbers are read in as integers without losing precision, all
+// other numbers are read in as floats
+func (loader *JSONFileLoader) ParseJSON(data []byte) (map[string]interface{}, error) {
 	config := map[string]interface{}{}
-	err := json.Unmarshal(bytes, &config)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure there's nothing but whitespace after the JSON object
+	if decoder.Decode(&struct{}{}) != io.EOF {
+		return nil, errors.New("invalid character after top-level JSON object")
+	}
+
+	// Convert the numbers into native types
+	_, err = convertJSONValue(nil, config)
 	if err != nil {
 		return nil, err
 	}
@@ -45,26 +67,88 @@ func (loader *JSONFileLoader) ParseJSON(bytes []byte) (map[string]interface{}, e
 	return config, nil
 }
 
-// ParseDurationStrings recursively loops through all the string values in the supplied map and parses them to a duration if possible
+// ParseDurationStrings recursively loops through all the string values in the supplied map, including those inside arrays,
+// and parses them to a duration if possible. When DurationKeys or DurationSuffix are set, only the matching keys are parsed
 func (loader *JSONFileLoader) ParseDurationStrings(m map[string]interface{}) map[string]interface{} {
-	for key, value := range m {
+	if len(loader.DurationKeys) == 0 && len(loader.DurationSuffix) == 0 {
+		return ParseDurationValues(m)
+	}
+	return ParseDurationValuesFunc(m, loader.isDurationKey)
+}
+
+// isDurationKey checks if the value under the supplied key path should be parsed to a duration
+func (loader *JSONFileLoader) isDurationKey(keys []string) bool {
+	if len(loader.DurationSuffix) > 0 && strings.HasSuffix(keys[len(keys)-1], loader.DurationSuffix) {
+		return true
+	}
 
-		// If the value is a string, apply duration parsing
-		stringValue, castStringValue := value.(string)
-		if castStringValue {
-			m[key] = ParseDurationString(stringValue)
-			continue
+	joinedKey := JoinKey(keys)
+	for _, durationKey := range loader.DurationKeys {
+		if durationKey == joinedKey {
+			return true
 		}
+	}
+	return false
+}
 
-		// If the value is not a map, keep going
-		mapValue, castMapValue := value.(map[string]interface{})
-		if !castMapValue {
-			continue
+// ConvertJSONValue recursively converts the json.Number values produced by a JSON decoder using UseNumber to native types.
+// Integral numbers become an int, or an int64 when they don't fit in an int, and all other numbers become a float64
+func ConvertJSONValue(value interface{}) (interface{}, error) {
+	return convertJSONValue(nil, value)
+}
+
+// convertJSONValue converts the json.Number values under the supplied keys to native types
+func convertJSONValue(keys []string, value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case json.Number:
+		return convertJSONNumber(keys, typedValue)
+	case map[string]interface{}:
+		for key, subValue := range typedValue {
+			convertedValue, err := convertJSONValue(append(keys[:len(keys):len(keys)], key), subValue)
+			if err != nil {
+				return nil, err
+			}
+			typedValue[key] = convertedValue
+		}
+		return typedValue, nil
+	case []interface{}:
+		for i, subValue := range typedValue {
+			convertedValue, err := convertJSONValue(append(keys[:len(keys):len(keys)], strconv.Itoa(i)), subValue)
+			if err != nil {
+				return nil, err
+			}
+			typedValue[i] = convertedValue
 		}
+		return typedValue, nil
+	default:
+		return value, nil
+	}
+}
+
+// convertJSONNumber converts a JSON number to an int, an int64 or a float64. Integral numbers too large for an int64 are
+// an error rather than being silently rounded
+func convertJSONNumber(keys []string, number json.Number) (interface{}, error) {
+	literal := number.String()
+	if strings.ContainsAny(literal, ".eE") {
+		return number.Float64()
+	}
+
+	intValue, err := number.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("key '%s': integer %s doesn't fit in a 64 bit integer", JoinKey(keys), literal)
+	}
+	return narrowInteger(intValue), nil
+}
 
-		// Value is a map, recurse
-		m[key] = loader.ParseDurationStrings(mapValue)
+// Describe describes which file supplied the supplied keys
+func (loader *JSONFileLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "JSON file",
+		Source: loader.FilePath,
 	}
+}
 
-	return m
+// Files returns the file read by this loader
+func (loader *JSONFileLoader) Files() []string {
+	return []string{loader.FilePath}
 }
@@ -0,0 +1,119 @@
+package lib
+
+import "fmt"
+
+// Layer describes a loader in the configuration loading chain
+type Layer struct {
+	Name     string // The name the layer was added with, empty for loaders added with Use
+	Priority int    // Layers with a higher priority win, loaders added with Use have a priority of 0
+	Loader   Loader
+	Keys     int // The number of leaf keys the layer last loaded
+}
+
+// UseLayer adds a named loader to the configuration loading chain. Layers with a higher priority win over layers with a lower
+// priority, and layers with the same priority win in the order they were added. Loaders added with Use have a priority of 0.
+// Adding a layer with the name of an existing layer replaces it, keeping the existing layer if the new one fails to load
+func (config *Config) UseLayer(name string, priority int, loader Loader) error {
+	if config.parent != nil {
+		return config.parent.UseLayer(name, priority, &prefixLoader{loader: loader, keys: config.prefix})
+	}
+
+	return config.addSource(&source{name: name, priority: priority, loader: loader, policy: Required})
+}
+
+// RemoveLayer removes a named loader from the configuration loading chain
+func (config *Config) RemoveLayer(name string) error {
+	if config.parent != nil {
+		return config.parent.RemoveLayer(name)
+	}
+
+	err := config.update(func() error {
+		index := config.layerIndex(name)
+		if index < 0 {
+			return fmt.Errorf("layer '%s' was not found", name)
+		}
+
+		return config.rebuild(append(config.sources[:index:index], config.sources[index+1:]...), config.overrides)
+	})
+	if err != nil {
+		return err
+	}
+
+	config.refreshWatchers()
+	return nil
+}
+
+// Layers lists the loaders in the configuration loading chain, from the highest priority to the lowest
+func (config *Config) Layers() []Layer {
+	if config.parent != nil {
+		return config.parent.Layers()
+	}
+
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+
+	layers := make([]Layer, len(config.sources))
+	for i, s := range config.sources {
+		layers[i] = Layer{
+			Name:     s.name,
+			Priority: s.priority,
+			Loader:   s.loader,
+			Keys:     len(s.origins),
+		}
+	}
+	return layers
+}
+
+// addSource loads a source and adds it to the chain behind every source with the same or a higher priority, replacing any
+// source with the same name, then rebuilds our values. The source is loaded before the lock is taken
+func (config *Config) addSource(s *source) error {
+	config.loading.Lock()
+	defer config.loading.Unlock()
+
+	s, err := s.load()
+	if err != nil {
+		return withDelimiter(err, config.keyDelimiter())
+	}
+
+	err = config.update(func() error {
+
+		// Replace the existing layer with the same name
+		sources := config.sources
+		existingIndex := config.layerIndex(s.name)
+		if existingIndex >= 0 {
+			sources = append(sources[:existingIndex:existingIndex], sources[existingIndex+1:]...)
+		}
+
+		// Insert the source behind every source with the same or a higher priority
+		index := len(sources)
+		for i, existing := range sources {
+			if existing.priority < s.priority {
+				index = i
+				break
+			}
+		}
+
+		return config.rebuild(append(sources[:index:index], append([]*source{s}, sources[index:]...)...), config.overrides)
+	})
+	if err != nil {
+		return err
+	}
+
+	config.refreshWatchers()
+	return nil
+}
+
+// layerIndex finds the position of the named source in the chain, returning -1 if there isn't one. Loaders added with Use
+// don't have a name, so they can't be found
+func (config *Config) layerIndex(name string) int {
+	if len(name) == 0 {
+		return -1
+	}
+
+	for i, s := range config.sources {
+		if s.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -16,3 +16,10 @@ func NewMapLoader(stringMap map[string]interface{}) *MapLoader {
 func (loader *MapLoader) Load() (map[string]interface{}, error) {
 	return loader.Map, nil
 }
+
+// Describe describes where the supplied keys came from
+func (loader *MapLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "map",
+	}
+}
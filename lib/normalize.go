@@ -0,0 +1,295 @@
+package lib
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyNormalization defines how keys are normalized so that the keys of different loaders meet in one tree
+type KeyNormalization int
+
+const (
+	// NormalizeNone keeps keys exactly as they were loaded. This is the default
+	NormalizeNone KeyNormalization = iota
+
+	// NormalizeCase matches keys case-insensitively, e.g. DB, Db and db are the same key
+	NormalizeCase
+
+	// NormalizeWords matches keys case-insensitively and ignores the differences between snake_case, kebab-case and
+	// camelCase, e.g. MAX_CONNS, max-conns and maxConns are the same key
+	NormalizeWords
+)
+
+// wordSeparators removes the characters that separate words in snake_case and kebab-case keys
+var wordSeparators = strings.NewReplacer("_", "", "-", "")
+
+// Normalize converts a key to its canonical form
+func (normalization KeyNormalization) Normalize(key string) string {
+	switch normalization {
+	case NormalizeCase:
+		return strings.ToLower(key)
+	case NormalizeWords:
+		return strings.ToLower(wordSeparators.Replace(key))
+	default:
+		return key
+	}
+}
+
+// NormalizeKeys converts every key in a nested key to its canonical form
+func (normalization KeyNormalization) NormalizeKeys(keys []string) []string {
+	if normalization == NormalizeNone {
+		return keys
+	}
+
+	normalizedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		normalizedKeys[i] = normalization.Normalize(key)
+	}
+	return normalizedKeys
+}
+
+// NormalizeMap recursively converts the keys of a map, including the maps inside slices, to their canonical form. Maps
+// whose keys end up the same are merged, for any other values the key that sorts first wins
+func (normalization KeyNormalization) NormalizeMap(m map[string]interface{}) map[string]interface{} {
+	if normalization == NormalizeNone {
+		return m
+	}
+	return normalization.normalizeMap(m, []string{}, []string{}, nil)
+}
+
+// normalizeMap converts the keys of a map under the supplied keys to their canonical form, recording the raw keys of every
+// normalized key if a map to record them in is supplied
+func (normalization KeyNormalization) normalizeMap(m map[string]interface{}, normalizedParent []string, rawParent []string, rawKeys map[string][]string) map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	normalized := make(map[string]interface{}, len(m))
+	for _, key := range keys {
+		normalizedKey := normalization.Normalize(key)
+		normalizedKeys := append(normalizedParent[:len(normalizedParent):len(normalizedParent)], normalizedKey)
+		raw := append(rawParent[:len(rawParent):len(rawParent)], key)
+
+		value := m[key]
+		mapValue, castMapValue := value.(map[string]interface{})
+		if castMapValue {
+			value = normalization.normalizeMap(mapValue, normalizedKeys, raw, rawKeys)
+		} else {
+			value = normalization.normalizeValue(value)
+		}
+
+		if rawKeys != nil && !Has(normalized, normalizedKey) {
+			rawKeys[JoinKey(normalizedKeys)] = raw
+		}
+		Merge(normalized, map[string]interface{}{normalizedKey: value})
+	}
+	return normalized
+}
+
+// normalizeValue converts the keys of the maps in a value to their canonical form
+func (normalization KeyNormalization) normalizeValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return normalization.NormalizeMap(typedValue)
+	case []interface{}:
+		normalized := make([]interface{}, len(typedValue))
+		for i, subValue := range typedValue {
+			normalized[i] = normalization.normalizeValue(subValue)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// rekey converts the canonical keys in the value of the supplied keys back to the names used by the fields of the supplied
+// type, so that it can be decoded by mapstructure. The keys of maps are data rather than field names, so they are restored
+// to the raw keys they were loaded or set with
+func (normalization KeyNormalization) rekey(value interface{}, t reflect.Type, keys []string,
+	rawKeys map[string][]string) interface{} {
+	if normalization == NormalizeNone || t == nil {
+		return value
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return restoreKeys(value, keys, rawKeys)
+	}
+
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() == reflect.Struct {
+			rekeyed := make(map[string]interface{}, len(typedValue))
+			for key, subValue := range typedValue {
+				rekeyed[key] = subValue
+			}
+			normalization.rekeyFields(typedValue, t, keys, rawKeys, rekeyed)
+			return rekeyed
+		}
+
+		if t.Kind() == reflect.Map {
+			rekeyed := make(map[string]interface{}, len(typedValue))
+			for key, subValue := range typedValue {
+				subKeys := appendKey(keys, key)
+				rekeyed[rawKey(subKeys, rawKeys)] = normalization.rekey(subValue, t.Elem(), subKeys, rawKeys)
+			}
+			return rekeyed
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			rekeyed := make([]interface{}, len(typedValue))
+			for i, subValue := range typedValue {
+				rekeyed[i] = normalization.rekey(subValue, t.Elem(), appendKey(keys, strconv.Itoa(i)), rawKeys)
+			}
+			return rekeyed
+		}
+	}
+	return value
+}
+
+// restoreKeys converts the canonical keys of the maps in the value of the supplied keys back to the raw keys they were
+// loaded or set with
+func restoreKeys(value interface{}, keys []string, rawKeys map[string][]string) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		restored := make(map[string]interface{}, len(typedValue))
+		for key, subValue := range typedValue {
+			subKeys := appendKey(keys, key)
+			restored[rawKey(subKeys, rawKeys)] = restoreKeys(subValue, subKeys, rawKeys)
+		}
+		return restored
+	case []interface{}:
+		restored := make([]interface{}, len(typedValue))
+		for i, subValue := range typedValue {
+			restored[i] = restoreKeys(subValue, appendKey(keys, strconv.Itoa(i)), rawKeys)
+		}
+		return restored
+	default:
+		return value
+	}
+}
+
+// rawKey finds the raw form of the last of the supplied canonical keys, keeping the canonical key if it wasn't recorded
+func rawKey(keys []string, rawKeys map[string][]string) string {
+	raw, found := rawKeys[JoinKey(keys)]
+	if !found {
+		return keys[len(keys)-1]
+	}
+	return raw[len(raw)-1]
+}
+
+// rekeyFields moves the values of a map with canonical keys to the names of the matching fields of a structure type.
+// Squashed structures share the map of their parent
+func (normalization KeyNormalization) rekeyFields(m map[string]interface{}, t reflect.Type, keys []string,
+	rawKeys map[string][]string, rekeyed map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			continue
+		}
+
+		tagParts := strings.Split(field.Tag.Get("mapstructure"), ",")
+		name := tagParts[0]
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && hasTagOption(tagParts[1:], "squash") {
+			normalization.rekeyFields(m, fieldType, keys, rawKeys, rekeyed)
+			continue
+		}
+
+		normalizedName := normalization.Normalize(name)
+		value, found := m[normalizedName]
+		if !found {
+			continue
+		}
+		delete(rekeyed, normalizedName)
+		rekeyed[name] = normalization.rekey(value, field.Type, appendKey(keys, normalizedName), rawKeys)
+	}
+}
+
+// hasTagOption checks if the options of a structure tag contain the supplied option
+func hasTagOption(options []string, option string) bool {
+	for _, existing := range options {
+		if existing == option {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizedSource defines the data of a source with its keys normalized, along with the raw keys of every normalized key
+type normalizedSource struct {
+	*source
+	data    map[string]interface{}
+	rawKeys map[string][]string
+}
+
+// normalizeSource normalizes the keys of a source's data
+func (normalization KeyNormalization) normalizeSource(s *source) normalizedSource {
+	if normalization == NormalizeNone {
+		return normalizedSource{source: s, data: s.data}
+	}
+
+	rawKeys := map[string][]string{}
+	return normalizedSource{
+		source:  s,
+		data:    normalization.normalizeMap(s.data, []string{}, []string{}, rawKeys),
+		rawKeys: rawKeys,
+	}
+}
+
+// describe describes where the supplied normalized keys came from using the origins recorded when the source was loaded
+func (s normalizedSource) describe(keys []string) Origin {
+	raw, found := s.rawKeys[JoinKey(keys)]
+	if !found {
+		raw = keys
+	}
+	return s.source.describe(raw)
+}
+
+// SetKeyNormalization sets how keys are normalized. Keys are normalized before loaded maps are merged and when getting,
+// setting and deleting values, so that e.g. DB__MAX_CONNS from the environment and "db": {"maxConns": 1} from a JSON file
+// override each other. ToStructure matches the normalized keys against the field names of the structure
+func (config *Config) SetKeyNormalization(normalization KeyNormalization) error {
+	if config.parent != nil {
+		return config.parent.SetKeyNormalization(normalization)
+	}
+
+	return config.update(func() error {
+		previous := config.normalization
+		config.normalization = normalization
+
+		err := config.rebuild(config.sources, config.overrides)
+		if err != nil {
+			config.normalization = previous
+		}
+		return err
+	})
+}
+
+// keys splits a key on the configuration's delimiter and converts it to its canonical form
+func (config *Config) keys(key string) []string {
+	root, _ := config.root()
+	root.mutex.RLock()
+	defer root.mutex.RUnlock()
+	return root.normalization.NormalizeKeys(SplitKeyWith(key, root.delimiter))
+}
+
+// splitKey splits a key on the configuration's delimiter, keeping the keys as they are
+func (config *Config) splitKey(key string) []string {
+	root, _ := config.root()
+	root.mutex.RLock()
+	defer root.mutex.RUnlock()
+	return SplitKeyWith(key, root.delimiter)
+}
@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Origin describes where a single configuration value was loaded from
+type Origin struct {
+	Loader string      // The type of loader that supplied the value, e.g. "environment"
+	Source string      // Where the loader read the value from, e.g. a file path or environment variable name
+	Value  interface{} // The value that was supplied
+}
+
+// String describes the loader and source of the origin, e.g. "JSON file config.json"
+func (origin Origin) String() string {
+	if len(origin.Source) == 0 {
+		return origin.Loader
+	}
+	return fmt.Sprintf("%s %s", origin.Loader, origin.Source)
+}
+
+// Describer is implemented by loaders that can describe where the keys they loaded came from
+type Describer interface {
+	Describe(keys []string) Origin
+}
+
+// Explanation describes where the value of a key came from and which lower priority values it shadowed
+type Explanation struct {
+	Key      string
+	Origin   Origin
+	Shadowed []Origin
+}
+
+// Describe describes where the supplied keys were loaded from by the supplied loader
+func Describe(loader Loader, keys []string) Origin {
+	describer, castDescriber := loader.(Describer)
+	if !castDescriber {
+		return Origin{Loader: fmt.Sprintf("%T", loader)}
+	}
+	return describer.Describe(keys)
+}
+
+// Origins flattens a loaded map into the origins of each of its leaf keys, as described by the supplied loader
+func Origins(loader Loader, m map[string]interface{}) map[string]Origin {
+	origins := map[string]Origin{}
+	collectOrigins(func(keys []string) Origin { return Describe(loader, keys) }, m, []string{}, origins)
+	return origins
+}
+
+// collectOrigins recursively records the origin of every leaf value in the supplied map, as described by the supplied function
+func collectOrigins(describe func(keys []string) Origin, m map[string]interface{}, parentKeys []string, origins map[string]Origin) {
+	for key, value := range m {
+		keys := append(append([]string{}, parentKeys...), key)
+
+		// Keep going until we hit a leaf
+		mapValue, castMapValue := value.(map[string]interface{})
+		if castMapValue && len(mapValue) > 0 {
+			collectOrigins(describe, mapValue, keys, origins)
+			continue
+		}
+
+		origin := describe(keys)
+		origin.Value = value
+		origins[JoinKey(keys)] = origin
+	}
+}
+
+// sourceRecorder remembers which raw source supplied each key loaded by a loader
+type sourceRecorder map[string]string
+
+// record records the source of the supplied keys
+func (recorder sourceRecorder) record(keys []string, source string) {
+	recorder[strings.Join(keys, "\x00")] = source
+}
+
+// recorded checks if a source was recorded for exactly the supplied keys
+func (recorder sourceRecorder) recorded(keys []string) bool {
+	_, found := recorder[strings.Join(keys, "\x00")]
+	return found
+}
+
+// lookup finds the source of the supplied keys. Values that were parsed into maps (e.g. JSON objects) are attributed to the
+// source of their closest recorded parent, and values built from several sources (e.g. indexed slices) to all of them
+func (recorder sourceRecorder) lookup(keys []string) string {
+	for i := len(keys); i > 0; i-- {
+		source, found := recorder[strings.Join(keys[:i], "\x00")]
+		if found {
+			return source
+		}
+	}
+
+	// Fall back to every source recorded under the keys
+	prefix := strings.Join(keys, "\x00") + "\x00"
+	recordedKeys := []string{}
+	for recordedKey := range recorder {
+		if strings.HasPrefix(recordedKey, prefix) {
+			recordedKeys = append(recordedKeys, recordedKey)
+		}
+	}
+	sort.Strings(recordedKeys)
+
+	sources := make([]string, len(recordedKeys))
+	for i, recordedKey := range recordedKeys {
+		sources[i] = recorder[recordedKey]
+	}
+	return strings.Join(sources, ", ")
+}
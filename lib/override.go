@@ -0,0 +1,160 @@
+package lib
+
+import "strings"
+
+// override defines a value that was set or deleted in memory. Overrides take priority over every loader and are kept when
+// reloading
+type override struct {
+	keys    []string
+	value   interface{}
+	deleted bool
+}
+
+// Set sets a value in the loaded configuration, overriding any loaded value. Maps and slices are copied, so modifying them
+// afterwards doesn't affect the configuration
+func (config *Config) Set(key string, value interface{}) error {
+	if config.parent != nil {
+		return config.parent.Set(config.fullKey(key), value)
+	}
+
+	keys := config.splitKey(key)
+	return config.update(func() error {
+		err := checkSet(config.data, config.normalization.NormalizeKeys(keys), 0)
+		if err != nil {
+			return err
+		}
+
+		return config.addOverride(override{keys: keys, value: deepCopyValue(value)})
+	})
+}
+
+// Delete removes a key, along with everything under it, from the loaded configuration. The key stays deleted until the
+// override is removed with Unset or ClearOverrides, even when reloading
+func (config *Config) Delete(key string) error {
+	if config.parent != nil {
+		return config.parent.Delete(config.fullKey(key))
+	}
+
+	keys := config.splitKey(key)
+	return config.update(func() error {
+		_, err := Get(config.data, config.normalization.NormalizeKeys(keys))
+		if err != nil {
+			return err
+		}
+
+		return config.addOverride(override{keys: keys, deleted: true})
+	})
+}
+
+// Unset removes the overrides made with Set or Delete at or under a key, so that the loaded values show through again
+func (config *Config) Unset(key string) error {
+	if config.parent != nil {
+		return config.parent.Unset(config.fullKey(key))
+	}
+
+	keys := config.splitKey(key)
+	return config.update(func() error {
+		return config.rebuild(config.sources, config.keptOverrides(keys))
+	})
+}
+
+// ClearOverrides removes every override made with Set or Delete, so that only the loaded values remain
+func (config *Config) ClearOverrides() error {
+	if config.parent != nil {
+		return config.parent.Unset(config.fullKey(""))
+	}
+
+	return config.update(func() error {
+		return config.rebuild(config.sources, nil)
+	})
+}
+
+// addOverride records an override, replacing any earlier overrides at or under the same key, and rebuilds our values
+func (config *Config) addOverride(o override) error {
+	return config.rebuild(config.sources, append(config.keptOverrides(o.keys), o))
+}
+
+// keptOverrides returns the overrides that aren't at or under the supplied keys
+func (config *Config) keptOverrides(keys []string) []override {
+	keys = config.normalization.NormalizeKeys(keys)
+	kept := []override{}
+	for _, o := range config.overrides {
+		if !hasKeyPrefix(config.normalization.NormalizeKeys(o.keys), keys) {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// normalizeOverride converts the keys of an override, and of any maps it sets, to their canonical form, recording the raw
+// keys it sets over those of the loaders. Overrides keep their raw keys so that they can be normalized again when the
+// normalization changes
+func (config *Config) normalizeOverride(o override, rawKeys map[string][]string) override {
+	if config.normalization == NormalizeNone {
+		return o
+	}
+
+	keys := config.normalization.NormalizeKeys(o.keys)
+	for i := range keys {
+		rawKeys[JoinKey(keys[:i+1])] = o.keys[:i+1]
+	}
+
+	value := o.value
+	mapValue, castMapValue := value.(map[string]interface{})
+	if castMapValue {
+		value = config.normalization.normalizeMap(mapValue, keys, o.keys, rawKeys)
+	} else {
+		value = config.normalization.normalizeValue(value)
+	}
+	return override{keys: keys, value: value, deleted: o.deleted}
+}
+
+// apply applies the override to a merged map and updates the recorded origins to match. Overrides that no longer fit the
+// map, e.g. an index into a slice that has shrunk since, are skipped
+func (o override) apply(m map[string]interface{}, origins map[string][]Origin) {
+	setOrigins := map[string]Origin{}
+	if o.deleted {
+		Delete(m, o.keys)
+	} else {
+		value := deepCopyValue(o.value)
+		if checkSet(m, o.keys, 0) != nil {
+			return
+		}
+		Replace(m, o.keys, value)
+		collectOrigins(func(keys []string) Origin { return Origin{Loader: "set"} }, Replace(map[string]interface{}{}, o.keys, value), []string{}, setOrigins)
+
+		// Parents of the key that are maps now no longer have values of their own
+		for i := 1; i < len(o.keys); i++ {
+			parent, _ := Get(m, o.keys[:i])
+			_, castMap := parent.(map[string]interface{})
+			if castMap {
+				delete(origins, JoinKey(o.keys[:i]))
+			}
+		}
+	}
+
+	// Values under the key that the override didn't set are gone, the ones it did set shadow the loaded values
+	joinedKey := JoinKey(o.keys)
+	for key := range origins {
+		_, isSet := setOrigins[key]
+		if !isSet && (key == joinedKey || strings.HasPrefix(key, joinedKey+":")) {
+			delete(origins, key)
+		}
+	}
+	for key, origin := range setOrigins {
+		origins[key] = append([]Origin{origin}, origins[key]...)
+	}
+}
+
+// hasKeyPrefix checks if the supplied keys are equal to or nested under the supplied prefix
+func hasKeyPrefix(keys []string, prefix []string) bool {
+	if len(keys) < len(prefix) {
+		return false
+	}
+	for i, key := range prefix {
+		if keys[i] != key {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package lib
+
+import "strings"
+
+// SubConfigMode defines how a sub-config relates to the configuration it was taken from
+type SubConfigMode int
+
+const (
+	// Detached sub-configs get their own copy of the sub-map. Changes to either configuration don't affect the other
+	Detached SubConfigMode = iota
+
+	// WriteThrough sub-configs are a view of the sub-map in their parent. They always read the parent's current values,
+	// and setting values, adding loaders or reloading them changes the parent
+	WriteThrough
+)
+
+// GetSubConfig gets a loaded submap as a detached configuration structure
+func (config *Config) GetSubConfig(key string) (*Config, error) {
+	return config.GetSubConfigWithMode(key, Detached)
+}
+
+// GetSubConfigWithMode gets a loaded submap as a configuration structure, relating it to this configuration according to
+// the supplied mode
+func (config *Config) GetSubConfigWithMode(key string, mode SubConfigMode) (*Config, error) {
+	value, err := config.GetMap(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write-through sub-configs always refer to the configuration that owns the data, even when taken from another view
+	root, prefix := config.root()
+	keys := append(prefix[:len(prefix):len(prefix)], config.keys(key)...)
+	if mode == WriteThrough {
+		return &Config{parent: root, prefix: keys}, nil
+	}
+
+	// Carry over the key normalization, the raw keys and the origins of every value in the sub-map
+	root.mutex.RLock()
+	normalization, delimiter := root.normalization, root.delimiter
+	originPrefix := JoinKey(keys) + ":"
+	rawKeys := map[string][]string{}
+	for normalizedKey, raw := range root.rawKeys {
+		if strings.HasPrefix(normalizedKey, originPrefix) {
+			rawKeys[strings.TrimPrefix(normalizedKey, originPrefix)] = raw[len(keys):]
+		}
+	}
+	origins := map[string][]Origin{}
+	sourceOrigins := map[string]Origin{}
+	for originKey, keyOrigins := range root.origins {
+		if strings.HasPrefix(originKey, originPrefix) {
+			subKey := strings.TrimPrefix(originKey, originPrefix)
+			origins[subKey] = keyOrigins
+			if raw, found := rawKeys[subKey]; found {
+				subKey = JoinKey(raw)
+			}
+			sourceOrigins[subKey] = keyOrigins[0]
+		}
+	}
+	root.mutex.RUnlock()
+
+	// The sub-map, with its raw keys, becomes the only source of the new config so that reloading it keeps its values, and
+	// its values keep describing where they originally came from
+	rawValue := restoreKeys(value, []string{}, rawKeys).(map[string]interface{})
+	return &Config{
+		data:          value,
+		origins:       origins,
+		sources:       []*source{{loader: NewMapLoader(rawValue), data: rawValue, origins: sourceOrigins}},
+		normalization: normalization,
+		rawKeys:       rawKeys,
+		delimiter:     delimiter,
+	}, nil
+}
+
+// root returns the configuration that owns the data of this configuration, along with the keys of this configuration's
+// values within it
+func (config *Config) root() (*Config, []string) {
+	if config.parent == nil {
+		return config, nil
+	}
+	return config.parent, config.prefix
+}
+
+// fullKey converts a key of this configuration to the matching key in the configuration that owns the data
+func (config *Config) fullKey(key string) string {
+	if config.parent == nil {
+		return key
+	}
+
+	keys := config.prefix
+	if len(key) > 0 {
+		keys = append(keys[:len(keys):len(keys)], config.keys(key)...)
+	}
+
+	config.parent.mutex.RLock()
+	defer config.parent.mutex.RUnlock()
+	return JoinKeyWith(keys, config.parent.delimiter)
+}
+
+// prefixLoader nests everything loaded by a loader under a key, so that loaders can be added to write-through sub-configs
+type prefixLoader struct {
+	loader Loader
+	keys   []string
+}
+
+// Load loads the underlying loader's configuration map and nests it under the loader's keys
+func (loader *prefixLoader) Load() (map[string]interface{}, error) {
+	loadedMap, err := loader.loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	_, err = Set(m, loader.keys, loadedMap)
+	return m, err
+}
+
+// Describe describes where the supplied keys came from using the underlying loader
+func (loader *prefixLoader) Describe(keys []string) Origin {
+	if len(keys) < len(loader.keys) {
+		return Describe(loader.loader, []string{})
+	}
+	return Describe(loader.loader, keys[len(loader.keys):])
+}
+
+// Files returns the files read by the underlying loader
+func (loader *prefixLoader) Files() []string {
+	fileLoader, castFileLoader := loader.loader.(FileLoader)
+	if !castFileLoader {
+		return nil
+	}
+	return fileLoader.Files()
+}
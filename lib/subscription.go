@@ -0,0 +1,78 @@
+package lib
+
+import "reflect"
+
+// ChangeHandler defines a function that is called with the old and new values of a key that changed
+type ChangeHandler func(oldValue interface{}, newValue interface{})
+
+// subscription defines a change handler registered for a key prefix
+type subscription struct {
+	keys    []string
+	handler ChangeHandler
+}
+
+// OnChange registers a handler that is called whenever a re-merge of the configuration (e.g. Use, Reload or Set) changes the
+// value under the supplied key. Nested maps and slices are compared deeply, so the handler is only called when something
+// actually changed. A missing value is passed as nil, and an empty key subscribes to the whole configuration. Handlers are
+// called in the order they were registered. The returned function removes the subscription
+func (config *Config) OnChange(key string, handler ChangeHandler) func() {
+	if config.parent != nil {
+		return config.parent.OnChange(config.fullKey(key), handler)
+	}
+
+	keys := []string{}
+	if len(key) > 0 {
+		keys = config.splitKey(key)
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	s := &subscription{keys: keys, handler: handler}
+	config.subscriptions = append(config.subscriptions, s)
+
+	return func() {
+		config.mutex.Lock()
+		defer config.mutex.Unlock()
+
+		for i, existing := range config.subscriptions {
+			if existing == s {
+				config.subscriptions = append(config.subscriptions[:i:i], config.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// changes works out which subscriptions are affected by the change between the supplied maps, returning a notification for
+// each of them in the order they were registered
+func (config *Config) changes(oldMap map[string]interface{}, newMap map[string]interface{}) []func() {
+	notifications := []func(){}
+	for _, s := range config.subscriptions {
+		keys := config.normalization.NormalizeKeys(s.keys)
+		oldValue := lookup(oldMap, keys)
+		newValue := lookup(newMap, keys)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		// Handlers get their own copies, since the values are part of the configuration
+		handler := s.handler
+		oldValue, newValue = deepCopyValue(oldValue), deepCopyValue(newValue)
+		notifications = append(notifications, func() { handler(oldValue, newValue) })
+	}
+	return notifications
+}
+
+// lookup gets the value of a nested key, returning nil if it doesn't exist. No keys refers to the whole map
+func lookup(m map[string]interface{}, keys []string) interface{} {
+	if len(keys) == 0 {
+		return m
+	}
+
+	value, err := Get(m, keys)
+	if err != nil {
+		return nil
+	}
+	return value
+}
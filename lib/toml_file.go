@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"io/ioutil"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLFileLoader defines a loader that loads configurations from a TOML file
+type TOMLFileLoader struct {
+	FilePath string
+}
+
+// NewTOMLFileLoader creates a new TOML file loader
+func NewTOMLFileLoader(filePath string) *TOMLFileLoader {
+	return &TOMLFileLoader{
+		FilePath: filePath,
+	}
+}
+
+// Load loads a TOML file
+func (loader *TOMLFileLoader) Load() (map[string]interface{}, error) {
+	file, err := ioutil.ReadFile(loader.FilePath)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+
+	return loader.ParseTOML(file)
+}
+
+// ParseTOML parses TOML into a configuration map. Integers are converted to int, datetimes are kept as time.Time and
+// arrays of tables are converted to []interface{}
+func (loader *TOMLFileLoader) ParseTOML(data []byte) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	_, err := toml.Decode(string(data), &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return ConvertTOMLValue(config).(map[string]interface{}), nil
+}
+
+// ConvertTOMLValue recursively converts the values produced by the TOML decoder to the types used by the rest of the library
+func ConvertTOMLValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case int64:
+		return narrowInteger(typedValue)
+	case map[string]interface{}:
+		for key, subValue := range typedValue {
+			typedValue[key] = ConvertTOMLValue(subValue)
+		}
+		return typedValue
+	case []map[string]interface{}:
+		slice := make([]interface{}, len(typedValue))
+		for i, subValue := range typedValue {
+			slice[i] = ConvertTOMLValue(subValue)
+		}
+		return slice
+	case []interface{}:
+		for i, subValue := range typedValue {
+			typedValue[i] = ConvertTOMLValue(subValue)
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
+
+// Describe describes which file supplied the supplied keys
+func (loader *TOMLFileLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "TOML file",
+		Source: loader.FilePath,
+	}
+}
+
+// Files returns the file read by this loader
+func (loader *TOMLFileLoader) Files() []string {
+	return []string{loader.FilePath}
+}
@@ -2,8 +2,9 @@ package lib
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
+	"math"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -15,103 +16,368 @@ func Has(m map[string]interface{}, key string) bool {
 	return keyExists
 }
 
-// Set sets the value of a nested key in the supplied map
+// Set sets the value of a nested key in the supplied map. Numeric keys index into slices, and the index one past the end of a
+// slice appends to it
 func Set(m map[string]interface{}, keys []string, value interface{}) (map[string]interface{}, error) {
-
-	// If we're not adding interface{} more keys, return this map
 	if len(keys) == 0 {
 		return m, nil
 	}
 
-	key := keys[0]
-	keyExists := Has(m, key)
+	_, err := set(m, keys, 0, value, false)
+	return m, err
+}
+
+// set sets the value of the nested key at the supplied depth of a map or a slice, keeping track of the full key for error
+// reporting. Existing values are only overwritten when requested. The updated map or slice is returned, since appending to a
+// slice may create a new one
+func set(container interface{}, keys []string, depth int, value interface{}, overwrite bool) (interface{}, error) {
+	key := keys[depth]
+
+	switch typedContainer := container.(type) {
+	case []interface{}:
+		index, err := childIndex(typedContainer, keys, depth)
+		if err != nil {
+			return container, err
+		}
+		exists := index < len(typedContainer)
+
+		var existing interface{}
+		if exists {
+			existing = typedContainer[index]
+		}
 
-	// Last key, just write it in and return
-	if len(keys) == 1 {
+		newValue, err := setChild(existing, exists, keys, depth, value, overwrite)
+		if err != nil {
+			return container, err
+		}
 
-		// Key that we're trying to set already exists
-		if keyExists {
-			return m, fmt.Errorf("configuration option '%s' already present", key)
+		if !exists {
+			return append(typedContainer, newValue), nil
+		}
+		typedContainer[index] = newValue
+		return typedContainer, nil
+	default:
+		m := container.(map[string]interface{})
+		existing, exists := m[key]
+
+		newValue, err := setChild(existing, exists, keys, depth, value, overwrite)
+		if err != nil {
+			return container, err
 		}
 
-		m[key] = value
+		m[key] = newValue
 		return m, nil
 	}
+}
 
-	// Initialize a new map. We'll put this in the parent map if there isn't already a key there
-	castValue := map[string]interface{}{}
+// setChild works out the new value of the key at the supplied depth, setting the nested key inside of it if there is one
+func setChild(existing interface{}, exists bool, keys []string, depth int, value interface{}, overwrite bool) (interface{}, error) {
 
-	// The key already exists but if it's a map we can still go into it
-	if keyExists {
-		var castSuccessfully bool
-		castValue, castSuccessfully = m[key].(map[string]interface{})
+	// Last key, just write it in
+	if depth == len(keys)-1 {
+		if exists && !overwrite {
+			return nil, &ConflictError{Key: JoinKey(keys), Existing: existing}
+		}
+		return value, nil
+	}
 
-		if !castSuccessfully {
-			return m, fmt.Errorf("configuration option '%s' already present and not a map", key)
+	// Go into the existing value if it's a map or a slice, otherwise start a new map. Slices are never replaced, keys that
+	// don't index into them are an error
+	if !exists || !isContainer(existing) {
+		if exists && !overwrite {
+			return nil, &ConflictError{Key: JoinKey(keys[:depth+1]), Existing: existing}
 		}
+		existing = map[string]interface{}{}
+	}
+
+	return set(existing, keys, depth+1, value, overwrite)
+}
+
+// isContainer checks if a value is a map or a slice that keys can be set in
+func isContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// childIndex works out which element of a slice the key at the supplied depth sets, where the index one past the end
+// appends. Keys that aren't indexes are a TypeError, and indexes outside of the slice are a RangeError
+func childIndex(slice []interface{}, keys []string, depth int) (int, error) {
+	index, valid := sliceIndex(keys[depth], len(slice)+1)
+	if valid {
+		return index, nil
 	}
 
-	// Recurse and add the next nested value
-	submap, err := Set(castValue, keys[1:], value)
+	_, err := strconv.Atoi(keys[depth])
 	if err != nil {
-		return m, err
+		typeError := newTypeError("map", slice)
+		typeError.Key = JoinKey(keys[:depth])
+		return 0, typeError
+	}
+	return 0, &RangeError{Key: JoinKey(keys[:depth+1]), Length: len(slice)}
+}
+
+// checkSet checks that the nested key at the supplied depth of a value can be set without running into a slice that the
+// key doesn't index into, without changing anything
+func checkSet(container interface{}, keys []string, depth int) error {
+	if depth == len(keys) {
+		return nil
 	}
 
-	m[key] = submap
-	return m, nil
+	switch typedContainer := container.(type) {
+	case map[string]interface{}:
+		return checkSet(typedContainer[keys[depth]], keys, depth+1)
+	case []interface{}:
+		index, err := childIndex(typedContainer, keys, depth)
+		if err != nil || index == len(typedContainer) {
+			return err
+		}
+		return checkSet(typedContainer[index], keys, depth+1)
+	default:
+		return nil
+	}
+}
+
+// sliceIndex parses a key as an index into a slice, checking that it is below the supplied limit
+func sliceIndex(key string, limit int) (int, bool) {
+	index, err := strconv.Atoi(key)
+	return index, err == nil && index >= 0 && index < limit
 }
 
-// Get gets the value of a nested key in the supplied map
+// Get gets the value of a nested key in the supplied map. Numeric keys index into slices
 func Get(m map[string]interface{}, keys []string) (interface{}, error) {
+	return get(m, keys, 0)
+}
+
+// get gets the value of the nested key at the supplied depth of a value, keeping track of the full key for error reporting
+func get(container interface{}, keys []string, depth int) (interface{}, error) {
+
+	// If there are no keys left, we found the value
+	if depth == len(keys) {
+		return container, nil
+	}
+
+	key := keys[depth]
+	var value interface{}
+	var exists bool
+
+	switch typedContainer := container.(type) {
+	case map[string]interface{}:
+		value, exists = typedContainer[key]
+	case []interface{}:
+		index, valid := sliceIndex(key, len(typedContainer))
+		if valid {
+			value, exists = typedContainer[index], true
+		}
+	default:
 
-	key := keys[0]
-	keyExists := Has(m, key)
+		// Not the last key in the chain, so the value needs to be a map
+		typeError := newTypeError("map", container)
+		typeError.Key = JoinKey(keys[:depth])
+		return nil, typeError
+	}
 
-	if !keyExists {
-		return nil, fmt.Errorf("key '%s' was not found", key)
+	if !exists {
+		return nil, &NotFoundError{Key: JoinKey(keys)}
 	}
+	return get(value, keys, depth+1)
+}
 
-	// If this is the last key, return the value
-	if len(keys) == 1 {
-		return m[key], nil
+// Replace sets the value of a nested key in the supplied map, overwriting the key if it's already present. Parent keys that
+// aren't maps or slices are replaced with a map. Slices are never replaced, so the map is left unchanged when a key doesn't
+// index into a slice in its way
+func Replace(m map[string]interface{}, keys []string, value interface{}) map[string]interface{} {
+	if len(keys) == 0 {
+		return m
 	}
 
-	// Not the last key in the chain, make sure the next key is a map
-	mapValue, castMapValue := m[key].(map[string]interface{})
-	if !castMapValue {
-		return nil, fmt.Errorf("key '%s' is not a map that can contain sub keys", key)
+	set(m, keys, 0, value, true)
+	return m
+}
+
+// Delete removes a nested key, along with everything under it, from the supplied map. Deleting an index of a slice removes
+// the element from the slice
+func Delete(m map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return m, nil
 	}
 
-	return Get(mapValue, keys[1:])
+	_, err := remove(m, keys, 0)
+	return m, err
 }
 
-// Merge merges two maps recursively
+// remove removes the nested key at the supplied depth of a value, keeping track of the full key for error reporting. The
+// updated map or slice is returned, since removing an element of a slice creates a new one
+func remove(container interface{}, keys []string, depth int) (interface{}, error) {
+	key := keys[depth]
+	last := depth == len(keys)-1
+
+	switch typedContainer := container.(type) {
+	case map[string]interface{}:
+		value, exists := typedContainer[key]
+		if !exists {
+			return container, &NotFoundError{Key: JoinKey(keys)}
+		}
+
+		// If this is the last key, remove it
+		if last {
+			delete(typedContainer, key)
+			return typedContainer, nil
+		}
+
+		newValue, err := remove(value, keys, depth+1)
+		if err != nil {
+			return container, err
+		}
+		typedContainer[key] = newValue
+		return typedContainer, nil
+	case []interface{}:
+		index, valid := sliceIndex(key, len(typedContainer))
+		if !valid {
+			return container, &NotFoundError{Key: JoinKey(keys)}
+		}
+
+		// If this is the last key, remove the element
+		if last {
+			return append(typedContainer[:index:index], typedContainer[index+1:]...), nil
+		}
+
+		newValue, err := remove(typedContainer[index], keys, depth+1)
+		if err != nil {
+			return container, err
+		}
+		typedContainer[index] = newValue
+		return typedContainer, nil
+	default:
+
+		// Not the last key in the chain, so the value needs to be a map
+		typeError := newTypeError("map", container)
+		typeError.Key = JoinKey(keys[:depth])
+		return container, typeError
+	}
+}
+
+// Merge merges two maps recursively. Values taken from map2 are copied, so the maps never share nested maps or slices
 func Merge(map1 map[string]interface{}, map2 map[string]interface{}) map[string]interface{} {
+	return merge(map1, map2, []string{}, nil)
+}
+
+// MergeFunc merges two maps recursively like Merge, calling the supplied function for every key that is a map in one of the
+// maps but not in the other. These keys keep the value from map1
+func MergeFunc(map1 map[string]interface{}, map2 map[string]interface{}, conflict func(keys []string, value1 interface{}, value2 interface{})) map[string]interface{} {
+	return merge(map1, map2, []string{}, conflict)
+}
+
+// merge merges the maps under the supplied parent keys, reporting conflicting types to the supplied function if there is one
+func merge(map1 map[string]interface{}, map2 map[string]interface{}, parentKeys []string, conflict func(keys []string, value1 interface{}, value2 interface{})) map[string]interface{} {
 
 	for key, value := range map2 {
 
-		// If we don't have the key in map 1, just take the whole thing
+		// If we don't have the key in map 1, just take a copy of the whole thing
 		if !Has(map1, key) {
-			map1[key] = value
+			map1[key] = deepCopyValue(value)
 			continue
 		}
 
 		// We have the key in map 1 and map 2, let's see if it's a map in both so we can merge those
 		map1Value, castMap1Value := map1[key].(map[string]interface{})
 		map2Value, castMap2Value := map2[key].(map[string]interface{})
+		keys := append(parentKeys[:len(parentKeys):len(parentKeys)], key)
 
-		// If we failed to cast one of these to a map then we can't merge them. Just ignore the key
+		// If we failed to cast one of these to a map then we can't merge them. Just ignore the key, letting the caller know
+		// if only one of them was a map
 		if !castMap1Value || !castMap2Value {
+			if castMap1Value != castMap2Value && conflict != nil {
+				conflict(keys, map1[key], value)
+			}
 			continue
 		}
 
 		// Both of them are maps, keep merging
-		map1[key] = Merge(map1Value, map2Value)
+		map1[key] = merge(map1Value, map2Value, keys, conflict)
 	}
 
 	return map1
 }
 
+// DeepCopy recursively copies the maps and slices in the supplied map
+func DeepCopy(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		copied[key] = deepCopyValue(value)
+	}
+	return copied
+}
+
+// deepCopyValue recursively copies a value if it is a map or a slice. Typed maps and slices, e.g. a []string, are copied
+// through reflection and keep their type
+func deepCopyValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return DeepCopy(typedValue)
+	case []interface{}:
+		copied := make([]interface{}, len(typedValue))
+		for i, subValue := range typedValue {
+			copied[i] = deepCopyValue(subValue)
+		}
+		return copied
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice:
+		if reflected.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(reflected.Type(), reflected.Len(), reflected.Len())
+		for i := 0; i < reflected.Len(); i++ {
+			copied.Index(i).Set(deepCopyReflected(reflected.Index(i)))
+		}
+		return copied.Interface()
+	case reflect.Map:
+		if reflected.IsNil() {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(reflected.Type(), reflected.Len())
+		iterator := reflected.MapRange()
+		for iterator.Next() {
+			copied.SetMapIndex(iterator.Key(), deepCopyReflected(iterator.Value()))
+		}
+		return copied.Interface()
+	default:
+		return value
+	}
+}
+
+// deepCopyReflected copies an element of a typed map or slice, keeping the element type
+func deepCopyReflected(value reflect.Value) reflect.Value {
+	copied := reflect.New(value.Type()).Elem()
+	if value.Kind() == reflect.Interface && value.IsNil() {
+		return copied
+	}
+
+	copiedValue := reflect.ValueOf(deepCopyValue(value.Interface()))
+	if copiedValue.IsValid() {
+		copied.Set(copiedValue)
+	}
+	return copied
+}
+
+// narrowInteger converts a 64 bit integer read by a loader to an int when it fits, otherwise the full 64 bit value is kept
+func narrowInteger(value int64) interface{} {
+	if int64(int(value)) != value {
+		return value
+	}
+	return int(value)
+}
+
 // ParseString parses a string into a variety of types
 func ParseString(value string) interface{} {
 
@@ -163,16 +429,126 @@ func ParseDurationString(value string) interface{} {
 	return value
 }
 
-// SplitKey splits the supplied key into an array
+// ParseDurationValues recursively loops through all the string values in the supplied map, including those inside arrays,
+// and parses them to a duration if possible
+func ParseDurationValues(m map[string]interface{}) map[string]interface{} {
+	return ParseDurationValuesFunc(m, nil)
+}
+
+// ParseDurationValuesFunc recursively parses the string values in the supplied map to a duration if possible, as long as the
+// supplied function accepts their key path. Array indexes aren't part of the key path, so the values inside an array are
+// matched by the key of the array. A nil function accepts every key path
+func ParseDurationValuesFunc(m map[string]interface{}, match func(keys []string) bool) map[string]interface{} {
+	for key, value := range m {
+		m[key] = parseDurationValue([]string{key}, value, match)
+	}
+	return m
+}
+
+// parseDurationValue parses the supplied value, or the values it contains, to a duration if possible
+func parseDurationValue(keys []string, value interface{}, match func(keys []string) bool) interface{} {
+	switch typedValue := value.(type) {
+	case string:
+		if match != nil && !match(keys) {
+			return value
+		}
+		return ParseDurationString(typedValue)
+	case map[string]interface{}:
+		for key, subValue := range typedValue {
+			typedValue[key] = parseDurationValue(append(keys[:len(keys):len(keys)], key), subValue, match)
+		}
+		return typedValue
+	case []interface{}:
+		for i, subValue := range typedValue {
+			typedValue[i] = parseDurationValue(keys, subValue, match)
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
+
+// KeyDelimiter is the default delimiter between the keys of a nested key
+const KeyDelimiter = ":"
+
+// SplitKey splits the supplied key into an array on the default delimiter
 func SplitKey(key string) []string {
-	return strings.Split(key, ":")
+	return SplitKeyWith(key, KeyDelimiter)
+}
+
+// SplitKeyWith splits the supplied key into an array on the supplied delimiter. A backslash escapes the delimiter or another
+// backslash, so that keys can contain them
+func SplitKeyWith(key string, delimiter string) []string {
+	if !strings.Contains(key, "\\") {
+		return strings.Split(key, delimiter)
+	}
+
+	keys := []string{}
+	current := strings.Builder{}
+	for i := 0; i < len(key); {
+		switch {
+		case key[i] == '\\' && strings.HasPrefix(key[i+1:], delimiter):
+			current.WriteString(delimiter)
+			i += 1 + len(delimiter)
+		case key[i] == '\\' && strings.HasPrefix(key[i+1:], "\\"):
+			current.WriteByte('\\')
+			i += 2
+		case strings.HasPrefix(key[i:], delimiter):
+			keys = append(keys, current.String())
+			current.Reset()
+			i += len(delimiter)
+		default:
+			current.WriteByte(key[i])
+			i++
+		}
+	}
+	return append(keys, current.String())
+}
+
+// JoinKey joins the supplied keys into a single key with the default delimiter
+func JoinKey(keys []string) string {
+	return JoinKeyWith(keys, KeyDelimiter)
+}
+
+// JoinKeyWith joins the supplied keys into a single key with the supplied delimiter, escaping any delimiters and backslashes
+// in the keys so that SplitKeyWith gives the same keys back
+func JoinKeyWith(keys []string, delimiter string) string {
+	joined := strings.Join(keys, delimiter)
+	if !strings.Contains(joined, "\\") && strings.Count(joined, delimiter) == len(keys)-1 {
+		return joined
+	}
+
+	escaper := strings.NewReplacer("\\", "\\\\", delimiter, "\\"+delimiter)
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = escaper.Replace(key)
+	}
+	return strings.Join(escaped, delimiter)
+}
+
+// castElements casts a generic slice to a typed slice by casting every element with the supplied scalar cast
+func castElements[T any](obj interface{}, typeName string, cast func(interface{}) (T, error)) ([]T, error) {
+	slice, castSlice := obj.([]interface{})
+	if !castSlice {
+		return nil, newTypeError(typeName+" slice", obj)
+	}
+
+	typedSlice := make([]T, len(slice))
+	for i, element := range slice {
+		typedElement, err := cast(element)
+		if err != nil {
+			return nil, newElementTypeError(typeName+" slice", obj, i, element)
+		}
+		typedSlice[i] = typedElement
+	}
+	return typedSlice, nil
 }
 
 // CastMap casts a value to a map
 func CastMap(obj interface{}) (map[string]interface{}, error) {
 	value, cast := obj.(map[string]interface{})
 	if !cast {
-		return nil, errors.New("failed to cast value to map")
+		return nil, newTypeError("map", obj)
 	}
 	return value, nil
 }
@@ -181,7 +557,7 @@ func CastMap(obj interface{}) (map[string]interface{}, error) {
 func CastSlice(obj interface{}) ([]interface{}, error) {
 	value, cast := obj.([]interface{})
 	if !cast {
-		return nil, errors.New("failed to cast value to slice")
+		return nil, newTypeError("slice", obj)
 	}
 	return value, nil
 }
@@ -190,7 +566,7 @@ func CastSlice(obj interface{}) ([]interface{}, error) {
 func CastStringSlice(obj interface{}) ([]string, error) {
 	value, cast := obj.([]string)
 	if !cast {
-		return nil, errors.New("failed to cast value to string slice")
+		return castElements(obj, "string", CastString)
 	}
 	return value, nil
 }
@@ -199,7 +575,7 @@ func CastStringSlice(obj interface{}) ([]string, error) {
 func CastString(obj interface{}) (string, error) {
 	value, cast := obj.(string)
 	if !cast {
-		return "", errors.New("failed to cast value to string")
+		return "", newTypeError("string", obj)
 	}
 	return value, nil
 }
@@ -209,10 +585,10 @@ func CastIntegerSlice(obj interface{}) ([]int, error) {
 	value, cast := obj.([]int)
 	if !cast {
 
-		// Try to cast it into a float slice (the default when reading JSON)
+		// Try to cast it into a float slice, otherwise convert it element by element
 		floatSlice, cast := obj.([]float64)
 		if !cast {
-			return nil, errors.New("failed to cast value to integer slice")
+			return castElements(obj, "integer", CastInteger)
 		}
 
 		// Managed to convert to a float slice, convert to a integer slice
@@ -221,7 +597,7 @@ func CastIntegerSlice(obj interface{}) ([]int, error) {
 			intSlice[i] = int(v)
 
 			if float64(intSlice[i]) != v {
-				return nil, errors.New("failed to cast value to integer slice")
+				return nil, newElementTypeError("integer slice", obj, i, v)
 			}
 		}
 
@@ -232,29 +608,67 @@ func CastIntegerSlice(obj interface{}) ([]int, error) {
 
 // CastInteger casts a value to an integer
 func CastInteger(obj interface{}) (int, error) {
-	value, cast := obj.(int) // Try a regular integer cast
-	if !cast {
+	switch value := obj.(type) {
+	case int:
+		return value, nil
+	case int64:
+
+		// 64 bit integers are read from files when they don't fit in an int, make sure this one does
+		if int64(int(value)) != value {
+			return 0, newTypeError("integer", obj)
+		}
+		return int(value), nil
+	case float64:
 
-		// Try to cast it into a float (the default when reading JSON) and then convert to int
-		floatValue, cast := obj.(float64)
-		if !cast {
-			return 0, errors.New("failed to cast value to integer")
+		// Make sure converting the float wouldn't truncate the value
+		if float64(int(value)) != value {
+			return 0, newTypeError("integer", obj)
 		}
+		return int(value), nil
+	default:
+		return 0, newTypeError("integer", obj)
+	}
+}
 
-		if float64(int(floatValue)) != floatValue {
-			return 0, errors.New("failed to cast value to integer")
+// CastInteger64 casts a value to a 64 bit integer
+func CastInteger64(obj interface{}) (int64, error) {
+	switch value := obj.(type) {
+	case int64:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case float64:
+
+		// Make sure converting the float wouldn't truncate the value
+		if float64(int64(value)) != value {
+			return 0, newTypeError("64 bit integer", obj)
 		}
+		return int64(value), nil
+	default:
+		return 0, newTypeError("64 bit integer", obj)
+	}
+}
 
-		return int(floatValue), nil
+// CastUnsignedInteger casts a value to an unsigned integer
+func CastUnsignedInteger(obj interface{}) (uint, error) {
+	value, cast := obj.(uint)
+	if cast {
+		return value, nil
 	}
-	return value, nil
+
+	// Any other integer works as long as it isn't negative
+	intValue, err := CastInteger64(obj)
+	if err != nil || intValue < 0 {
+		return 0, newTypeError("unsigned integer", obj)
+	}
+	return uint(intValue), nil
 }
 
 // CastBooleanSlice casts a value to a boolean slice
 func CastBooleanSlice(obj interface{}) ([]bool, error) {
 	value, cast := obj.([]bool)
 	if !cast {
-		return nil, errors.New("failed to cast value to boolean slice")
+		return castElements(obj, "boolean", CastBoolean)
 	}
 	return value, nil
 }
@@ -263,7 +677,7 @@ func CastBooleanSlice(obj interface{}) ([]bool, error) {
 func CastBoolean(obj interface{}) (bool, error) {
 	value, cast := obj.(bool)
 	if !cast {
-		return false, errors.New("failed to cast value to boolean")
+		return false, newTypeError("boolean", obj)
 	}
 	return value, nil
 }
@@ -272,16 +686,150 @@ func CastBoolean(obj interface{}) (bool, error) {
 func CastFloatSlice(obj interface{}) ([]float64, error) {
 	value, cast := obj.([]float64)
 	if !cast {
-		return nil, errors.New("failed to cast value to float slice")
+		return castElements(obj, "float", CastFloat)
 	}
 	return value, nil
 }
 
-// CastFloat casts a value to a float
+// CastFloat casts a value to a float. Integers are converted, since files store whole numbers as integers
 func CastFloat(obj interface{}) (float64, error) {
-	value, cast := obj.(float64)
+	switch value := obj.(type) {
+	case float64:
+		return value, nil
+	case int:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	default:
+		return 0, newTypeError("float", obj)
+	}
+}
+
+// CastDurationSlice casts a value to a duration slice
+func CastDurationSlice(obj interface{}) ([]time.Duration, error) {
+	value, cast := obj.([]time.Duration)
+	if !cast {
+		return castElements(obj, "duration", CastDuration)
+	}
+	return value, nil
+}
+
+// CastDuration casts a value to a duration. Durations, duration strings and numbers of seconds, including numbers left as
+// strings, are supported
+func CastDuration(obj interface{}) (time.Duration, error) {
+	switch value := obj.(type) {
+	case time.Duration:
+		return value, nil
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err == nil {
+			return duration, nil
+		}
+		seconds, isNumber := parseNumberString(value)
+		if !isNumber {
+			return 0, newTypeError("duration", obj)
+		}
+		return CastDuration(seconds)
+	case int:
+		return time.Duration(value) * time.Second, nil
+	case int64:
+		return time.Duration(value) * time.Second, nil
+	case float64:
+		return time.Duration(value * float64(time.Second)), nil
+	default:
+		return 0, newTypeError("duration", obj)
+	}
+}
+
+// parseNumberString parses a string holding a finite number, e.g. a number of seconds from a loader that doesn't infer
+// types, into an integer or a float
+func parseNumberString(value string) (interface{}, bool) {
+	intValue, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return narrowInteger(intValue), true
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(floatValue, 0) || math.IsNaN(floatValue) {
+		return nil, false
+	}
+	return floatValue, true
+}
+
+// CastTimeSlice casts a value to a time slice
+func CastTimeSlice(obj interface{}) ([]time.Time, error) {
+	value, cast := obj.([]time.Time)
 	if !cast {
-		return 0, errors.New("failed to cast value to float")
+		return castElements(obj, "time", CastTime)
 	}
 	return value, nil
 }
+
+// CastTime casts a value to a time. Times, RFC3339 timestamps and numbers of seconds since the Unix epoch, including numbers
+// left as strings, are supported
+func CastTime(obj interface{}) (time.Time, error) {
+	switch value := obj.(type) {
+	case time.Time:
+		return value, nil
+	case string:
+		parsedTime, err := time.Parse(time.RFC3339Nano, value)
+		if err == nil {
+			return parsedTime, nil
+		}
+		seconds, isNumber := parseNumberString(value)
+		if !isNumber {
+			return time.Time{}, newTypeError("time", obj)
+		}
+		return CastTime(seconds)
+	case int:
+		return time.Unix(int64(value), 0), nil
+	case int64:
+		return time.Unix(value, 0), nil
+	case float64:
+		seconds := int64(value)
+		return time.Unix(seconds, int64((value-float64(seconds))*float64(time.Second))), nil
+	default:
+		return time.Time{}, newTypeError("time", obj)
+	}
+}
+
+// CastURL casts a value to a URL
+func CastURL(obj interface{}) (*url.URL, error) {
+	switch value := obj.(type) {
+	case *url.URL:
+		return value, nil
+	case url.URL:
+		return &value, nil
+	case string:
+		parsedURL, err := url.Parse(value)
+		if err != nil {
+			return nil, newTypeError("URL", obj)
+		}
+		return parsedURL, nil
+	default:
+		return nil, newTypeError("URL", obj)
+	}
+}
+
+// CastStringMap casts a value to a map of strings
+func CastStringMap(obj interface{}) (map[string]string, error) {
+	value, cast := obj.(map[string]string)
+	if cast {
+		return value, nil
+	}
+
+	// Try to cast it into a generic map and convert every value to a string
+	mapValue, cast := obj.(map[string]interface{})
+	if !cast {
+		return nil, newTypeError("string map", obj)
+	}
+
+	stringMap := make(map[string]string, len(mapValue))
+	for key, subValue := range mapValue {
+		stringValue, cast := subValue.(string)
+		if !cast {
+			return nil, newTypeError("string map", obj)
+		}
+		stringMap[key] = stringValue
+	}
+	return stringMap, nil
+}
@@ -0,0 +1,347 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Validator defines a function that validates a value decoded by ToStructure
+type Validator func(value interface{}) error
+
+var validators = map[reflect.Type]Validator{}
+var validatorsMutex sync.RWMutex
+
+// RegisterValidator registers a function that validates every T decoded by ToStructure, replacing any validator already
+// registered for T. Validators only run on values that were present in the configuration, and on the structure itself
+func RegisterValidator[T any](validate func(value T) error) {
+	validatorsMutex.Lock()
+	defer validatorsMutex.Unlock()
+
+	validators[typeOf[T]()] = func(value interface{}) error {
+		return validate(value.(T))
+	}
+}
+
+// Violation describes a key whose value broke a validation rule
+type Violation struct {
+	Key     string // The full key of the value, empty for the structure itself
+	Rule    string // The rule that was broken, e.g. "required", "min" or the type a registered validator was registered for
+	Message string // What was wrong with the value
+}
+
+// validation collects the violations found while validating a decoded structure
+type validation struct {
+	delimiter  string
+	violations []Violation
+}
+
+// validate validates a structure decoded from the supplied data against the `validate` and `pattern` tags of its fields and
+// the registered validators. Every violation is collected into a single ValidationError, with keys joined by the supplied
+// delimiter
+func validate(structure interface{}, data interface{}, delimiter string) error {
+	value := reflect.ValueOf(structure)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	validation := &validation{delimiter: delimiter}
+	err := validation.validateValue([]string{}, value, data, true, map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+	if len(validation.violations) > 0 {
+		return &ValidationError{Violations: validation.violations}
+	}
+	return nil
+}
+
+// validateValue runs the registered validator of a value if it was present, then validates everything nested inside of it
+func (validation *validation) validateValue(keys []string, value reflect.Value, data interface{}, present bool,
+	visiting map[reflect.Type]bool) error {
+	if present {
+		validation.runValidator(keys, value)
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			return validation.validateValue(keys, value.Elem(), data, present, visiting)
+		}
+
+		// Missing structures are still followed so that their required fields are reported, validateFields leaves out the
+		// optional sections behind nil pointers that aren't required
+		nested, isNested := nestedStructure(value, visiting)
+		if !present && isNested {
+			return validation.validateValue(keys, nested, nil, false, visiting)
+		}
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return nil
+		}
+		visiting[value.Type()] = true
+		defer delete(visiting, value.Type())
+
+		dataMap, _ := data.(map[string]interface{})
+		return validation.validateFields(keys, value, dataMap, visiting)
+	case reflect.Slice, reflect.Array:
+		dataSlice, _ := data.([]interface{})
+		for i := 0; i < value.Len(); i++ {
+			var element interface{}
+			if i < len(dataSlice) {
+				element = dataSlice[i]
+			}
+			err := validation.validateValue(appendKey(keys, strconv.Itoa(i)), value.Index(i), element, present, visiting)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		dataMap, _ := data.(map[string]interface{})
+		mapKeys := value.MapKeys()
+		sort.Slice(mapKeys, func(i, j int) bool { return mapKeys[i].String() < mapKeys[j].String() })
+		for _, mapKey := range mapKeys {
+			err := validation.validateValue(appendKey(keys, mapKey.String()), value.MapIndex(mapKey), dataMap[mapKey.String()], present,
+				visiting)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateFields checks the rules of every field of a structure, squashed structures share the data of their parent
+func (validation *validation) validateFields(keys []string, value reflect.Value, data map[string]interface{},
+	visiting map[reflect.Type]bool) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, squash, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		if squash {
+			nested, isNested := nestedStructure(value.Field(i), visiting)
+			if isNested {
+				err := validation.validateFields(keys, nested, data, visiting)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		fieldKeys := appendKey(keys, name)
+		fieldData, present := lookupField(data, name)
+		rules := fieldRules(field)
+		err := validation.checkRules(fieldKeys, rules, value.Field(i), present)
+		if err != nil {
+			return err
+		}
+
+		// Sections behind nil pointers are optional, so their fields are only required once the section is present or
+		// required itself
+		fieldValue := value.Field(i)
+		if !present && fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() && !hasRule(rules, "required") {
+			continue
+		}
+
+		err = validation.validateValue(fieldKeys, value.Field(i), fieldData, present, visiting)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldRules reads the rules in the `validate` and `pattern` tags of a field
+func fieldRules(field reflect.StructField) []string {
+	rules := []string{}
+	if tag := field.Tag.Get("validate"); len(tag) > 0 {
+		rules = strings.Split(tag, ",")
+	}
+	if pattern, hasPattern := field.Tag.Lookup("pattern"); hasPattern {
+		rules = append(rules, "pattern="+pattern)
+	}
+	return rules
+}
+
+// hasRule checks if the supplied rules include the named rule
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		ruleName, _, _ := strings.Cut(rule, "=")
+		if ruleName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRules checks the value of a field against its rules. Only `required` applies to missing keys. Malformed rules are
+// returned as an error rather than a violation
+func (validation *validation) checkRules(keys []string, rules []string, value reflect.Value, present bool) error {
+	for _, rule := range rules {
+		name, argument, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if !present {
+				validation.violate(keys, name, "is required")
+			}
+			continue
+		}
+
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if !present || value.Kind() == reflect.Ptr {
+			continue
+		}
+
+		message, err := checkRule(name, argument, value)
+		if err != nil {
+			return fmt.Errorf("key '%s': invalid validation rule '%s': %w", JoinKeyWith(keys, validation.delimiter), rule, err)
+		}
+		if len(message) > 0 {
+			validation.violate(keys, name, message)
+		}
+	}
+	return nil
+}
+
+// checkRule checks a value against a single rule, describing the violation if there is one
+func checkRule(name string, argument string, value reflect.Value) (string, error) {
+	switch name {
+	case "min", "max":
+		return checkBound(name, argument, value)
+	case "enum":
+		options := strings.Split(argument, "|")
+		for _, option := range options {
+			if option == valueString(value) {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(options, ", ")), nil
+	case "pattern":
+		expression, err := regexp.Compile(argument)
+		if err != nil {
+			return "", err
+		}
+		if !expression.MatchString(valueString(value)) {
+			return fmt.Sprintf("must match the pattern '%s'", argument), nil
+		}
+		return "", nil
+	default:
+		return "", errors.New("unknown rule")
+	}
+}
+
+// checkBound checks a value against a min or max rule. Numbers are compared by value, durations by duration and strings,
+// slices and maps by length
+func checkBound(name string, argument string, value reflect.Value) (string, error) {
+	var actual, bound float64
+	var err error
+	comparison := "be"
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+		if value.Type() == durationType {
+			var duration time.Duration
+			duration, err = time.ParseDuration(argument)
+			bound = float64(duration)
+			break
+		}
+		bound, err = strconv.ParseFloat(argument, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+		bound, err = strconv.ParseFloat(argument, 64)
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+		bound, err = strconv.ParseFloat(argument, 64)
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(value.Len())
+		bound, err = strconv.ParseFloat(argument, 64)
+		comparison = "have a length of"
+	default:
+		return "", fmt.Errorf("can't be applied to a %s", value.Type())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if name == "min" && actual < bound {
+		return fmt.Sprintf("must %s at least %s", comparison, argument), nil
+	}
+	if name == "max" && actual > bound {
+		return fmt.Sprintf("must %s at most %s", comparison, argument), nil
+	}
+	return "", nil
+}
+
+// runValidator runs the validator registered for the type of a value, if there is one
+func (validation *validation) runValidator(keys []string, value reflect.Value) {
+	if !value.IsValid() || !value.CanInterface() {
+		return
+	}
+
+	validatorsMutex.RLock()
+	validator, found := validators[value.Type()]
+	validatorsMutex.RUnlock()
+	if !found {
+		return
+	}
+
+	err := validator(value.Interface())
+	if err != nil {
+		validation.violate(keys, value.Type().String(), err.Error())
+	}
+}
+
+// violate records a violation of a rule by the value of the supplied keys
+func (validation *validation) violate(keys []string, rule string, message string) {
+	validation.violations = append(validation.violations, Violation{Key: JoinKeyWith(keys, validation.delimiter), Rule: rule,
+		Message: message})
+}
+
+// lookupField finds the data a field was decoded from. Like mapstructure, names are matched without regard to case when
+// there's no exact match
+func lookupField(data map[string]interface{}, name string) (interface{}, bool) {
+	value, found := data[name]
+	if found {
+		return value, true
+	}
+	for key, value := range data {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// valueString formats a value for the enum and pattern rules
+func valueString(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+	if !value.CanInterface() {
+		return ""
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// appendKey appends a key to a copy of the supplied keys
+func appendKey(keys []string, key string) []string {
+	return append(keys[:len(keys):len(keys)], key)
+}
@@ -0,0 +1,323 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileLoader is implemented by loaders that read their configuration from files
+type FileLoader interface {
+	Files() []string
+}
+
+// WatchOptions defines how a configuration's files are watched
+type WatchOptions struct {
+	Debounce     time.Duration // How long to wait for a burst of changes to settle before reloading, defaults to 100ms
+	PollInterval time.Duration // How often files are checked when polling, defaults to 1s
+	Poll         bool          // Forces polling even when file system notifications are available
+	OnError      func(error)   // Called with any error encountered while watching or reloading
+}
+
+// fingerprint identifies a version of a watched file
+type fingerprint struct {
+	path    string
+	size    int64
+	modTime time.Time
+	exists  bool
+}
+
+// Watcher watches the files read by a configuration's loaders and reloads the configuration when they change
+type Watcher struct {
+	config       *Config
+	options      WatchOptions
+	files        []string
+	fingerprints map[string]fingerprint
+	directories  map[string]bool // The directories watched for notifications
+	mutex        sync.Mutex      // Guards the files, their fingerprints and the directories
+	notify       *fsnotify.Watcher
+	done         chan struct{}
+	wait         sync.WaitGroup
+	closeOnce    sync.Once
+	closeError   error
+}
+
+// Watch starts watching the files read by the configuration's loaders, reloading the whole configuration when they change.
+// The files of loaders added or removed later, e.g. with UseLayer, are watched from then on
+func (config *Config) Watch(options WatchOptions) (*Watcher, error) {
+	if config.parent != nil {
+		return config.parent.Watch(options)
+	}
+
+	watcher, err := NewWatcher(config, config.watchedFiles(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+	config.watchers = append(config.watchers, watcher)
+	config.mutex.Unlock()
+	return watcher, nil
+}
+
+// watchedFiles lists the files read by the configuration's loaders
+func (config *Config) watchedFiles() []string {
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+
+	files := []string{}
+	for _, s := range config.sources {
+		fileLoader, castFileLoader := s.loader.(FileLoader)
+		if castFileLoader {
+			files = append(files, fileLoader.Files()...)
+		}
+	}
+	return files
+}
+
+// refreshWatchers points the configuration's watchers at the files of its current loaders
+func (config *Config) refreshWatchers() {
+	config.mutex.RLock()
+	watchers := config.watchers
+	config.mutex.RUnlock()
+	if len(watchers) == 0 {
+		return
+	}
+
+	files := config.watchedFiles()
+	for _, watcher := range watchers {
+		err := watcher.setFiles(files)
+		if err != nil {
+			watcher.reportError(err)
+		}
+	}
+}
+
+// removeWatcher stops refreshing a closed watcher
+func (config *Config) removeWatcher(watcher *Watcher) {
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	watchers := []*Watcher{}
+	for _, existing := range config.watchers {
+		if existing != watcher {
+			watchers = append(watchers, existing)
+		}
+	}
+	config.watchers = watchers
+}
+
+// NewWatcher creates a new watcher that reloads the configuration when any of the supplied files change
+func NewWatcher(config *Config, files []string, options WatchOptions) (*Watcher, error) {
+	if options.Debounce <= 0 {
+		options.Debounce = 100 * time.Millisecond
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+
+	watcher := &Watcher{
+		config:       config,
+		options:      options,
+		fingerprints: map[string]fingerprint{},
+		directories:  map[string]bool{},
+		done:         make(chan struct{}),
+	}
+
+	// Fall back to polling whenever file system notifications aren't available
+	if !options.Poll {
+		watcher.notify = watcher.startNotify()
+	}
+
+	err := watcher.setFiles(files)
+	if err != nil && watcher.notify != nil {
+
+		// Watching a directory failed after all, so poll instead
+		watcher.notify.Close()
+		watcher.notify = nil
+		err = watcher.setFiles(files)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	watcher.wait.Add(1)
+	if watcher.notify != nil {
+		go watcher.watchNotify()
+	} else {
+		go watcher.watchPoll()
+	}
+
+	return watcher, nil
+}
+
+// Close stops watching files. Closing a watcher more than once does nothing, and returns the result of the first close
+func (watcher *Watcher) Close() error {
+	watcher.closeOnce.Do(func() {
+		watcher.config.removeWatcher(watcher)
+		close(watcher.done)
+		watcher.wait.Wait()
+
+		if watcher.notify != nil {
+			watcher.closeError = watcher.notify.Close()
+		}
+	})
+	return watcher.closeError
+}
+
+// startNotify creates a file system notification watcher, returning nil if notifications aren't available
+func (watcher *Watcher) startNotify() *fsnotify.Watcher {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil
+	}
+	return notify
+}
+
+// setFiles replaces the watched files. Files are resolved up front so that changing the working directory doesn't break
+// anything, and only files that weren't already watched are fingerprinted. The directories containing the files are watched
+// rather than the files themselves, so that atomic renames and symlink swaps (e.g. from Kubernetes ConfigMaps) are picked up.
+// Files are still replaced when a directory can't be watched, and the first error is returned
+func (watcher *Watcher) setFiles(files []string) error {
+	absoluteFiles := make([]string, len(files))
+	for i, file := range files {
+		absoluteFile, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		absoluteFiles[i] = absoluteFile
+	}
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	var addError error
+	fingerprints := make(map[string]fingerprint, len(absoluteFiles))
+	for _, file := range absoluteFiles {
+		existing, found := watcher.fingerprints[file]
+		if !found {
+			existing = fingerprintFile(file)
+		}
+		fingerprints[file] = existing
+
+		directory := filepath.Dir(file)
+		if watcher.notify == nil || watcher.directories[directory] {
+			continue
+		}
+		err := watcher.notify.Add(directory)
+		if err != nil {
+			if addError == nil {
+				addError = err
+			}
+			continue
+		}
+		watcher.directories[directory] = true
+	}
+
+	watcher.files, watcher.fingerprints = absoluteFiles, fingerprints
+	return addError
+}
+
+// watchNotify waits for file system notifications, checking the files once a burst of events has settled. Events for the
+// watched files themselves always reload, since a rewrite can leave a file's size and modification time unchanged
+func (watcher *Watcher) watchNotify() {
+	defer watcher.wait.Done()
+
+	var debounce <-chan time.Time
+	touched := false
+	for {
+		select {
+		case <-watcher.done:
+			return
+		case event := <-watcher.notify.Events:
+			touched = touched || watcher.watches(event.Name)
+			debounce = time.After(watcher.options.Debounce)
+		case err := <-watcher.notify.Errors:
+			watcher.reportError(err)
+		case <-debounce:
+			debounce = nil
+			watcher.check(touched)
+			touched = false
+		}
+	}
+}
+
+// watches checks if a file is one of the watched files
+func (watcher *Watcher) watches(file string) bool {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	_, found := watcher.fingerprints[filepath.Clean(file)]
+	return found
+}
+
+// watchPoll periodically checks the files for changes
+func (watcher *Watcher) watchPoll() {
+	defer watcher.wait.Done()
+
+	ticker := time.NewTicker(watcher.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-watcher.done:
+			return
+		case <-ticker.C:
+			watcher.check(false)
+		}
+	}
+}
+
+// check reloads the configuration if any of the files changed since they were last checked, or straight away if forced to
+func (watcher *Watcher) check(force bool) {
+	watcher.mutex.Lock()
+	changed := force
+	for _, file := range watcher.files {
+		current := fingerprintFile(file)
+		if current != watcher.fingerprints[file] {
+			watcher.fingerprints[file] = current
+			changed = true
+		}
+	}
+	watcher.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	err := watcher.config.Reload()
+	if err != nil {
+		watcher.reportError(err)
+	}
+}
+
+// reportError passes an error to the configured error handler
+func (watcher *Watcher) reportError(err error) {
+	if watcher.options.OnError != nil {
+		watcher.options.OnError(err)
+	}
+}
+
+// fingerprintFile identifies the current version of a file. Symlinks are resolved so that swapping the target of a symlink
+// counts as a change even when the target files look identical
+func fingerprintFile(file string) fingerprint {
+	path, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return fingerprint{}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fingerprint{}
+	}
+
+	return fingerprint{
+		path:    path,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		exists:  true,
+	}
+}
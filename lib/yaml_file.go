@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// YAMLFileLoader defines a loader that loads configurations from a YAML file
+type YAMLFileLoader struct {
+	FilePath       string
+	ParseDurations bool
+	MultiDocument  bool
+}
+
+// NewYAMLFileLoader creates a new YAML file loader
+func NewYAMLFileLoader(filePath string, parseDurations bool) *YAMLFileLoader {
+	return &YAMLFileLoader{
+		FilePath:       filePath,
+		ParseDurations: parseDurations,
+	}
+}
+
+// Load loads a YAML file
+func (loader *YAMLFileLoader) Load() (map[string]interface{}, error) {
+	file, err := ioutil.ReadFile(loader.FilePath)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+
+	return loader.ParseYAML(file)
+}
+
+// ParseYAML parses YAML into a configuration map. When multi document parsing is enabled, every document in the stream is
+// merged into the result, with earlier documents winning in case of collisions
+func (loader *YAMLFileLoader) ParseYAML(data []byte) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Convert the document into a string keyed map and merge it in
+		documentMap, err := loader.ConvertYAML(document)
+		if err != nil {
+			return nil, err
+		}
+		Merge(config, documentMap)
+
+		// Only the first document is read unless we were configured otherwise
+		if !loader.MultiDocument {
+			break
+		}
+	}
+
+	// If we were configured to parse durations, do that
+	if loader.ParseDurations {
+		return ParseDurationValues(config), nil
+	}
+
+	return config, nil
+}
+
+// ConvertYAML converts a decoded YAML document into a configuration map
+func (loader *YAMLFileLoader) ConvertYAML(document interface{}) (map[string]interface{}, error) {
+
+	// Empty documents decode to nil, treat them as an empty map
+	if document == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	value := ConvertYAMLValue(document)
+	mapValue, castMapValue := value.(map[string]interface{})
+	if !castMapValue {
+		return nil, fmt.Errorf("YAML document must be a mapping, got %T", document)
+	}
+
+	return mapValue, nil
+}
+
+// ConvertYAMLValue recursively converts the map[interface{}]interface{} nodes produced by the YAML decoder to string keyed maps
+func ConvertYAMLValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(typedValue))
+		for key, subValue := range typedValue {
+			m[fmt.Sprint(key)] = ConvertYAMLValue(subValue)
+		}
+		return m
+	case []interface{}:
+		for i, subValue := range typedValue {
+			typedValue[i] = ConvertYAMLValue(subValue)
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
+
+// Describe describes which file supplied the supplied keys
+func (loader *YAMLFileLoader) Describe(keys []string) Origin {
+	return Origin{
+		Loader: "YAML file",
+		Source: loader.FilePath,
+	}
+}
+
+// Files returns the file read by this loader
+func (loader *YAMLFileLoader) Files() []string {
+	return []string{loader.FilePath}
+}
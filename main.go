@@ -5,6 +5,18 @@ import (
 	"sync"
 )
 
+// Loader policies that can be passed to UseWithPolicy
+const (
+	Required = lib.Required
+	Optional = lib.Optional
+)
+
+// Sub-config modes that can be passed to GetSubConfigWithMode
+const (
+	Detached     = lib.Detached
+	WriteThrough = lib.WriteThrough
+)
+
 var configSingleton *lib.Config
 var once sync.Once
 
@@ -36,7 +48,22 @@ func JSONFile(filePath string, parseDurations bool) *lib.JSONFileLoader {
 	return lib.NewJSONFileLoader(filePath, parseDurations)
 }
 
+// YAMLFile creates a new YAML file loader
+func YAMLFile(filePath string, parseDurations bool) *lib.YAMLFileLoader {
+	return lib.NewYAMLFileLoader(filePath, parseDurations)
+}
+
+// TOMLFile creates a new TOML file loader
+func TOMLFile(filePath string) *lib.TOMLFileLoader {
+	return lib.NewTOMLFileLoader(filePath)
+}
+
 // Map creates a new map laoder
 func Map(stringMap map[string]interface{}) *lib.MapLoader {
 	return lib.NewMapLoader(stringMap)
 }
+
+// Defaults creates a new loader for the defaults of a structure
+func Defaults(structure interface{}) *lib.DefaultsLoader {
+	return lib.NewDefaultsLoader(structure)
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"errors"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -25,9 +26,9 @@ func TestParseArguments(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
-		Convey("Ignores arguments without an '='", func() {
-			result, err := loader.ParseArguments([]string{"testing"})
-			So(result, ShouldBeEmpty)
+		Convey("Parses arguments starting with '-' without an '=' as flags", func() {
+			result, err := loader.ParseArguments([]string{"-verbose"})
+			So(result, ShouldResemble, map[string]interface{}{"verbose": true})
 			So(err, ShouldBeNil)
 		})
 
@@ -76,3 +77,111 @@ func TestParseArguments(t *testing.T) {
 		})
 	})
 }
+
+func TestParseArgumentFormats(t *testing.T) {
+	loader := lib.NewArgumentLoader("", "")
+
+	Convey("Parses values separated by a space", t, func() {
+		result, err := loader.ParseArguments([]string{"--port", "8080", "--name", "test"})
+		So(result, ShouldResemble, map[string]interface{}{"port": 8080, "name": "test"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Parses negative numbers separated by a space", t, func() {
+		result, err := loader.ParseArguments([]string{"--offset", "-5"})
+		So(result, ShouldResemble, map[string]interface{}{"offset": -5})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Parses bare flags as true", t, func() {
+		result, err := loader.ParseArguments([]string{"--verbose", "--debug"})
+		So(result, ShouldResemble, map[string]interface{}{"verbose": true, "debug": true})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Doesn't take the next argument as the value of flags hinted as booleans", t, func() {
+		loader := lib.NewArgumentLoader(":", "")
+		loader.TypeHints = map[string]lib.TypeHint{"verbose": lib.BooleanHint, "log:color": lib.BooleanHint}
+		result, err := loader.ParseArguments([]string{"--verbose", "build", "--log:color", "target", "--quiet=false", "--level", "2"})
+		So(result, ShouldResemble, map[string]interface{}{
+			"verbose": true, "log": map[string]interface{}{"color": true}, "quiet": false, "level": 2,
+		})
+		So(loader.Positional, ShouldResemble, []string{"build", "target"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Parses flags prefixed with 'no-' as false", t, func() {
+		result, err := loader.ParseArguments([]string{"--no-color", "file"})
+		So(result, ShouldResemble, map[string]interface{}{"color": false})
+		So(loader.Positional, ShouldResemble, []string{"file"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Keeps any '=' after the first one in the value", t, func() {
+		result, err := loader.ParseArguments([]string{"--url=http://x?a=b"})
+		So(result, ShouldResemble, map[string]interface{}{"url": "http://x?a=b"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Collects repeated options into a slice", t, func() {
+		result, err := loader.ParseArguments([]string{"--tag=a", "--tag", "b", "--tag=c"})
+		So(result, ShouldResemble, map[string]interface{}{"tag": []interface{}{"a", "b", "c"}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Collects repeated nested options into a slice", t, func() {
+		loader := lib.NewArgumentLoader("__", "")
+		result, err := loader.ParseArguments([]string{"--db__host=a", "--db__host=b"})
+		So(result, ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": []interface{}{"a", "b"}}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Exposes positional arguments", t, func() {
+		result, err := loader.ParseArguments([]string{"first", "--verbose=true", "second", "-"})
+		So(result, ShouldResemble, map[string]interface{}{"verbose": true})
+		So(loader.Positional, ShouldResemble, []string{"first", "second", "-"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Stops parsing options at the '--' terminator", t, func() {
+		result, err := loader.ParseArguments([]string{"--verbose", "--", "--port=8080", "file"})
+		So(result, ShouldResemble, map[string]interface{}{"verbose": true})
+		So(loader.Positional, ShouldResemble, []string{"--port=8080", "file"})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Skips the values of options without the prefix", t, func() {
+		loader := lib.NewArgumentLoader("", "app-")
+		result, err := loader.ParseArguments([]string{"--other", "value", "--app-port", "8080", "--no-app-color"})
+		So(result, ShouldResemble, map[string]interface{}{"port": 8080, "color": false})
+		So(loader.Positional, ShouldBeEmpty)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Builds slices from indexed options when enabled", t, func() {
+		loader := lib.NewArgumentLoader(":", "")
+		loader.IndexedSlices = true
+		result, err := loader.ParseArguments([]string{"--servers:0:host=a", "--servers:1:host", "b", "--servers:1:tls"})
+		So(result, ShouldResemble, map[string]interface{}{"servers": []interface{}{
+			map[string]interface{}{"host": "a"},
+			map[string]interface{}{"host": "b", "tls": true},
+		}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Splits lists when a list separator is configured", t, func() {
+		loader := lib.NewArgumentLoader("", "")
+		loader.ListSeparator = ","
+		result, err := loader.ParseArguments([]string{"--tags=a,b", "--ports", "80,443"})
+		So(result, ShouldResemble, map[string]interface{}{"tags": []interface{}{"a", "b"}, "ports": []interface{}{80, 443}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns an error for options that conflict with an element of a slice", t, func() {
+		loader := lib.NewArgumentLoader(":", "")
+		_, err := loader.ParseArguments([]string{"--x=[1,2]", "--x:0=5"})
+		var conflictError *lib.ConflictError
+		So(errors.As(err, &conflictError), ShouldBeTrue)
+		So(conflictError.Key, ShouldEqual, "x:0")
+	})
+}
@@ -1,7 +1,14 @@
 package test
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/thalmic/gconf/lib"
@@ -12,19 +19,92 @@ func TestUse(t *testing.T) {
 	Convey("Adds a loaded config to the config map", t, func() {
 		config := lib.NewConfig()
 		config.Use(lib.NewMapLoader(map[string]interface{}{"one": 1}))
-		So(config.Map, ShouldResemble, map[string]interface{}{"one": 1})
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1})
 	})
 
 	Convey("Merges the new config with previous configs", t, func() {
 		config := lib.NewConfig()
 		config.Use(lib.NewMapLoader(map[string]interface{}{"one": 1}))
 		config.Use(lib.NewMapLoader(map[string]interface{}{"two": 2}))
-		So(config.Map, ShouldResemble, map[string]interface{}{"one": 1, "two": 2})
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1, "two": 2})
+	})
+
+	Convey("Returns an error if the config failed to load", t, func() {
+		config := lib.NewConfig()
+		err := config.Use(lib.NewJSONFileLoader("", false))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestUseWithPolicy(t *testing.T) {
+
+	Convey("Required loaders", t, func() {
+
+		Convey("Return an error if the source doesn't exist", func() {
+			config := lib.NewConfig()
+			err := config.UseWithPolicy(lib.NewJSONFileLoader("non-existent.json", false), lib.Required)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Optional loaders", t, func() {
+
+		Convey("Are ignored if the source doesn't exist", func() {
+			config := lib.NewConfig()
+			err := config.UseWithPolicy(lib.NewJSONFileLoader("non-existent.json", false), lib.Optional)
+			So(config.Snapshot(), ShouldBeEmpty)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Are ignored if a wrapped error says the source doesn't exist", func() {
+			config := lib.NewConfig()
+			err := config.UseWithPolicy(&wrappingLoader{path: "non-existent.json"}, lib.Optional)
+			So(config.Snapshot(), ShouldBeEmpty)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Are loaded if the source exists", func() {
+			config := lib.NewConfig()
+			err := config.UseWithPolicy(lib.NewMapLoader(map[string]interface{}{"one": 1}), lib.Optional)
+			So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Return an error for failures other than a missing source", func() {
+			config := lib.NewConfig()
+			err := config.UseWithPolicy(lib.NewJSONFileLoader("config_test.go", false), lib.Optional)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Can be mixed in a single chain", t, func() {
+		config := lib.NewConfig()
+		So(config.UseWithPolicy(lib.NewJSONFileLoader("non-existent.json", false), lib.Optional), ShouldBeNil)
+		So(config.UseWithPolicy(lib.NewMapLoader(map[string]interface{}{"one": 1}), lib.Required), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1})
+	})
+}
+
+type wrappingLoader struct {
+	path string
+}
+
+func (loader *wrappingLoader) Load() (map[string]interface{}, error) {
+	_, err := ioutil.ReadFile(loader.path)
+	return nil, fmt.Errorf("failed to read %s: %w", loader.path, err)
+}
+
+func TestMustUse(t *testing.T) {
+
+	Convey("Adds a loaded config to the config map", t, func() {
+		config := lib.NewConfig()
+		config.MustUse(lib.NewMapLoader(map[string]interface{}{"one": 1}))
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1})
 	})
 
 	Convey("Panics if the config failed to load", t, func() {
 		config := lib.NewConfig()
-		So(func() { config.Use(lib.NewJSONFileLoader("", false)) }, ShouldPanic)
+		So(func() { config.MustUse(lib.NewJSONFileLoader("", false)) }, ShouldPanic)
 	})
 }
 
@@ -76,7 +156,7 @@ func TestGetters(t *testing.T) {
 
 		Convey("Constructs a new config object containing the sub-map", func() {
 			value, err := config.GetSubConfig("Map")
-			So(value.Map, ShouldResemble, map[string]interface{}{"Two": "Hi"})
+			So(value.Snapshot(), ShouldResemble, map[string]interface{}{"Two": "Hi"})
 			So(err, ShouldBeNil)
 		})
 
@@ -87,3 +167,333 @@ func TestGetters(t *testing.T) {
 		})
 	})
 }
+
+func TestExplain(t *testing.T) {
+	config := lib.NewConfig()
+	config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "map-host"}}))
+	config.Use(lib.NewJSONFileLoader("test.json", false))
+	config.Set("db:port", 5432)
+
+	Convey("Describes the loader that supplied a value", t, func() {
+		explanation, err := config.Explain("object:string")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "object:string")
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "JSON file", Source: "test.json", Value: "woohoo"})
+		So(explanation.Shadowed, ShouldBeEmpty)
+	})
+
+	Convey("Describes values that were set in memory", t, func() {
+		explanation, err := config.Explain("db:port")
+		So(err, ShouldBeNil)
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "set", Value: 5432})
+	})
+
+	Convey("Lists lower priority values that were shadowed", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "first"}}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "second"}}))
+
+		explanation, err := config.Explain("db:host")
+		So(err, ShouldBeNil)
+		So(explanation.Origin.Value, ShouldEqual, "first")
+		So(explanation.Shadowed, ShouldResemble, []lib.Origin{{Loader: "map", Value: "second"}})
+	})
+
+	Convey("Carries origins over to sub-configs", t, func() {
+		subConfig, err := config.GetSubConfig("db")
+		So(err, ShouldBeNil)
+		explanation, err := subConfig.Explain("host")
+		So(err, ShouldBeNil)
+		So(explanation.Origin.Value, ShouldEqual, "map-host")
+	})
+
+	Convey("Returns an error if the key doesn't exist", t, func() {
+		explanation, err := config.Explain("non-existent")
+		So(explanation, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error if the key isn't a leaf", t, func() {
+		explanation, err := config.Explain("db")
+		So(explanation, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+type failingLoader struct {
+	fail bool
+}
+
+func (loader *failingLoader) Load() (map[string]interface{}, error) {
+	if loader.fail {
+		return nil, errors.New("failed to load")
+	}
+	return map[string]interface{}{"failing": "loaded"}, nil
+}
+
+func TestReload(t *testing.T) {
+
+	Convey("Re-runs the loaders in order and rebuilds the configuration", t, func() {
+		first := lib.NewMapLoader(map[string]interface{}{"one": 1})
+		second := lib.NewMapLoader(map[string]interface{}{"one": 10, "two": 2})
+		config := lib.NewConfig()
+		config.Use(first)
+		config.Use(second)
+
+		first.Map = map[string]interface{}{"three": 3}
+		err := config.Reload()
+		So(err, ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 10, "two": 2, "three": 3})
+	})
+
+	Convey("Picks up changes to files", t, func() {
+		directory, err := ioutil.TempDir("", "gconf")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+		path := filepath.Join(directory, "config.json")
+		So(ioutil.WriteFile(path, []byte(`{"a": "before"}`), 0644), ShouldBeNil)
+
+		config := lib.NewConfig()
+		So(config.Use(lib.NewJSONFileLoader(path, false)), ShouldBeNil)
+		So(ioutil.WriteFile(path, []byte(`{"a": "after"}`), 0644), ShouldBeNil)
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"a": "after"})
+	})
+
+	Convey("Picks up optional sources that didn't exist when they were used", t, func() {
+		directory, err := ioutil.TempDir("", "gconf")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+		path := filepath.Join(directory, "config.json")
+
+		config := lib.NewConfig()
+		So(config.UseWithPolicy(lib.NewJSONFileLoader(path, false), lib.Optional), ShouldBeNil)
+		So(config.Snapshot(), ShouldBeEmpty)
+		So(ioutil.WriteFile(path, []byte(`{"a": "b"}`), 0644), ShouldBeNil)
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"a": "b"})
+	})
+
+	Convey("Keeps values that were set in memory", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"one": 1}))
+		config.Set("two", 2)
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1, "two": 2})
+	})
+
+	Convey("Doesn't modify the data returned by loaders", t, func() {
+		first := lib.NewMapLoader(map[string]interface{}{"map": map[string]interface{}{"one": 1}})
+		config := lib.NewConfig()
+		config.Use(first)
+		config.Use(lib.NewMapLoader(map[string]interface{}{"map": map[string]interface{}{"two": 2}}))
+		So(config.Reload(), ShouldBeNil)
+		So(first.Map, ShouldResemble, map[string]interface{}{"map": map[string]interface{}{"one": 1}})
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"map": map[string]interface{}{"one": 1, "two": 2}})
+	})
+
+	Convey("Leaves the old configuration in place if a loader fails", t, func() {
+		loader := &failingLoader{}
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"one": 1}))
+		config.Use(loader)
+
+		loader.fail = true
+		err := config.Reload()
+		So(err, ShouldNotBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1, "failing": "loaded"})
+	})
+
+	Convey("Keeps the values of sub-configs", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"map": map[string]interface{}{"one": 1}}))
+		subConfig, err := config.GetSubConfig("map")
+		So(err, ShouldBeNil)
+		So(subConfig.Reload(), ShouldBeNil)
+		So(subConfig.Snapshot(), ShouldResemble, map[string]interface{}{"one": 1})
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+
+	Convey("Returns a copy of the loaded configuration", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"map": map[string]interface{}{"one": 1}}))
+
+		snapshot := config.Snapshot()
+		So(snapshot, ShouldResemble, map[string]interface{}{"map": map[string]interface{}{"one": 1}})
+
+		snapshot["map"].(map[string]interface{})["one"] = 10
+		value, err := config.GetInteger("map:one")
+		So(value, ShouldEqual, 1)
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestConcurrency(t *testing.T) {
+
+	Convey("Allows concurrent reads and writes", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"map": map[string]interface{}{"one": 1}}))
+
+		var wait sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wait.Add(2)
+			go func(i int) {
+				defer wait.Done()
+				config.Set(fmt.Sprintf("map:key%d", i), i)
+				config.Reload()
+			}(i)
+			go func() {
+				defer wait.Done()
+				config.GetInteger("map:one")
+				config.GetSubConfig("map")
+				config.Snapshot()
+			}()
+		}
+		wait.Wait()
+
+		subConfig, err := config.GetSubConfig("map")
+		So(err, ShouldBeNil)
+		So(len(subConfig.Snapshot()), ShouldEqual, 11)
+	})
+
+	Convey("Allows loaders to read the configuration while loading", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"remote": map[string]interface{}{"url": "https://example.com"}}))
+		loader := &readingLoader{config: config}
+
+		done := make(chan error)
+		go func() {
+			err := config.UseLayer("remote", 100, loader)
+			if err == nil {
+				err = config.Reload()
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			So(err, ShouldBeNil)
+		case <-time.After(5 * time.Second):
+			t.Fatal("loading a loader that reads the configuration deadlocked")
+		}
+		So(loader.url, ShouldEqual, "https://example.com")
+		So(config.Snapshot()["fetched"], ShouldEqual, "https://example.com")
+	})
+}
+
+type readingLoader struct {
+	config *lib.Config
+	url    string
+}
+
+func (loader *readingLoader) Load() (map[string]interface{}, error) {
+	url, err := loader.config.GetString("remote:url")
+	if err != nil {
+		return nil, err
+	}
+	loader.url = url
+	return map[string]interface{}{"fetched": url}, nil
+}
+
+func TestDurationAndTimeGetters(t *testing.T) {
+	directory, err := ioutil.TempDir("", "gconf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "config.json")
+	err = ioutil.WriteFile(path, []byte(`{"string": "3s", "seconds": 3, "time": "2018-05-27T07:32:00Z", "slice": ["1s", 2]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	environment := lib.NewEnvironmentLoader(true, "", "")
+	environmentMap, err := environment.ParseEnvironment([]string{"PARSED=3s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stringEnvironment := lib.NewEnvironmentLoader(true, "", "")
+	stringEnvironment.Inference = lib.InferStrings
+	stringEnvironmentMap, err := stringEnvironment.ParseEnvironment([]string{"TIMEOUT=30", "INTERVAL=1.5", "STARTED=1527406320"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := lib.NewConfig()
+	config.Use(lib.NewJSONFileLoader(path, false))
+	config.Use(lib.NewMapLoader(environmentMap))
+	config.Use(lib.NewMapLoader(stringEnvironmentMap))
+
+	Convey("GetDuration", t, func() {
+
+		Convey("Gets durations parsed by a loader", func() {
+			value, err := config.GetDuration("parsed")
+			So(value, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Gets durations left as strings", func() {
+			value, err := config.GetDuration("string")
+			So(value, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Gets durations specified in seconds", func() {
+			value, err := config.GetDuration("seconds")
+			So(value, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Gets seconds left as strings by a loader", func() {
+			value, err := config.GetDuration("timeout")
+			So(value, ShouldEqual, 30*time.Second)
+			So(err, ShouldBeNil)
+
+			value, err = config.GetDuration("interval")
+			So(value, ShouldEqual, 1500*time.Millisecond)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the key isn't a duration", func() {
+			value, err := config.GetDuration("time")
+			So(value, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("GetDurationSlice", t, func() {
+		value, err := config.GetDurationSlice("slice")
+		So(value, ShouldResemble, []time.Duration{time.Second, 2 * time.Second})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("GetTime", t, func() {
+
+		Convey("Gets RFC3339 timestamps", func() {
+			value, err := config.GetTime("time")
+			So(value, ShouldEqual, time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Gets Unix timestamps left as strings by a loader", func() {
+			value, err := config.GetTime("started")
+			So(value, ShouldEqual, time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the key doesn't exist", func() {
+			value, err := config.GetTime("non-existent")
+			So(value, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("GetTimeSlice", t, func() {
+		value, err := config.GetTimeSlice("slice")
+		So(value, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestConflicts(t *testing.T) {
+
+	Convey("Reports keys that are a map in one loader but not in another", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": "postgres", "port": 80}))
+		config.Use(lib.NewJSONFileLoader("test.json", false))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"object": map[string]interface{}{"float": map[string]interface{}{"a": 1}}}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+
+		So(config.Conflicts(), ShouldResemble, []lib.MergeConflict{
+			{
+				Key:     "db",
+				Kept:    lib.Origin{Loader: "map", Value: "postgres"},
+				Ignored: lib.Origin{Loader: "map", Value: map[string]interface{}{"host": "localhost"}},
+			},
+			{
+				Key:     "object:float",
+				Kept:    lib.Origin{Loader: "JSON file", Source: "test.json", Value: 3.5},
+				Ignored: lib.Origin{Loader: "map", Value: map[string]interface{}{"a": 1}},
+			},
+		})
+	})
+
+	Convey("Doesn't report values that simply shadow each other", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "first"}}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "second"}}))
+		So(config.Conflicts(), ShouldBeEmpty)
+	})
+
+	Convey("Clears conflicts that go away", t, func() {
+		config := lib.NewConfig()
+		config.UseLayer("remote", 10, lib.NewMapLoader(map[string]interface{}{"db": "postgres"}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+		So(config.Conflicts(), ShouldHaveLength, 1)
+
+		config.RemoveLayer("remote")
+		So(config.Conflicts(), ShouldBeEmpty)
+	})
+}
+
+func TestStrict(t *testing.T) {
+
+	Convey("Returns an error and keeps the configuration when loaders conflict", t, func() {
+		config := lib.NewConfig()
+		config.SetStrict(true)
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+
+		err := config.Use(lib.NewMapLoader(map[string]interface{}{"db": "postgres"}))
+		var conflictError *lib.MergeConflictError
+		So(errors.As(err, &conflictError), ShouldBeTrue)
+		So(errors.Is(err, lib.ErrConflict), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "key 'db': map[string]interface {} from map conflicts with string from map")
+		So(conflictError.Conflicts, ShouldHaveLength, 1)
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+		So(config.Layers(), ShouldHaveLength, 1)
+	})
+
+	Convey("Keeps the configuration when a reload introduces a conflict", t, func() {
+		defaults := map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}
+		config := lib.NewConfig()
+		config.SetStrict(true)
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"port": 5432}}))
+		config.Use(lib.NewMapLoader(defaults))
+
+		defaults["db"] = "postgres"
+		So(config.Reload(), ShouldNotBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}})
+	})
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+type logLevel int
+
+func TestConvert(t *testing.T) {
+
+	Convey("Returns values that already have the requested type", t, func() {
+		result, err := lib.Convert[string]("one")
+		So(result, ShouldEqual, "one")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Converts values using the registered converter", t, func() {
+		result, err := lib.Convert[int](float64(1))
+		So(result, ShouldEqual, 1)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns the converter's error", t, func() {
+		result, err := lib.Convert[int]("one")
+		So(result, ShouldBeZeroValue)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error when no converter is registered for the type", t, func() {
+		result, err := lib.Convert[complex128]("one")
+		So(result, ShouldBeZeroValue)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Comes with converters for", t, func() {
+
+		Convey("Unsigned integers", func() {
+			result, err := lib.Convert[uint](10)
+			So(result, ShouldEqual, uint(10))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("64 bit integers", func() {
+			result, err := lib.Convert[int64](float64(10))
+			So(result, ShouldEqual, int64(10))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Durations", func() {
+			result, err := lib.Convert[time.Duration]("3s")
+			So(result, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Times", func() {
+			result, err := lib.Convert[time.Time]("2018-05-27T07:32:00Z")
+			So(result, ShouldEqual, time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("URLs", func() {
+			result, err := lib.Convert[url.URL]("https://example.com/path")
+			So(result.Host, ShouldEqual, "example.com")
+			So(err, ShouldBeNil)
+
+			pointer, err := lib.Convert[*url.URL]("https://example.com/path")
+			So(pointer.Path, ShouldEqual, "/path")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("String maps", func() {
+			result, err := lib.Convert[map[string]string](map[string]interface{}{"a": "b"})
+			So(result, ShouldResemble, map[string]string{"a": "b"})
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestRegisterConverter(t *testing.T) {
+
+	Convey("Registers converters for custom types", t, func() {
+		lib.RegisterConverter(func(value interface{}) (net.IP, error) {
+			stringValue, err := lib.CastString(value)
+			if err != nil {
+				return nil, err
+			}
+			ip := net.ParseIP(stringValue)
+			if ip == nil {
+				return nil, errors.New("failed to cast value to IP")
+			}
+			return ip, nil
+		})
+		lib.RegisterConverter(func(value interface{}) (logLevel, error) {
+			levels := map[interface{}]logLevel{"debug": 0, "info": 1}
+			level, found := levels[value]
+			if !found {
+				return 0, errors.New("unknown log level")
+			}
+			return level, nil
+		})
+
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"ip": "10.0.0.1", "level": "info"}))
+
+		ip, err := lib.GetAs[net.IP](config, "ip")
+		So(ip.String(), ShouldEqual, "10.0.0.1")
+		So(err, ShouldBeNil)
+
+		level, err := lib.GetAs[logLevel](config, "level")
+		So(level, ShouldEqual, logLevel(1))
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestGetAs(t *testing.T) {
+	config := lib.NewConfig()
+	config.Use(lib.NewMapLoader(map[string]interface{}{"port": 8080, "timeout": "3s"}))
+
+	Convey("Gets a key converted to the requested type", t, func() {
+		result, err := lib.GetAs[uint](config, "port")
+		So(result, ShouldEqual, uint(8080))
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns an error if the key doesn't exist", t, func() {
+		result, err := lib.GetAs[uint](config, "non-existent")
+		So(result, ShouldBeZeroValue)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error if the key can't be converted", t, func() {
+		result, err := lib.GetAs[time.Duration](config, "port:sub")
+		So(result, ShouldBeZeroValue)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestGetOr(t *testing.T) {
+	config := lib.NewConfig()
+	config.Use(lib.NewMapLoader(map[string]interface{}{"timeout": "3s"}))
+
+	Convey("Gets a key converted to the requested type", t, func() {
+		So(lib.GetOr(config, "timeout", time.Second), ShouldEqual, 3*time.Second)
+	})
+
+	Convey("Returns the default if the key doesn't exist", t, func() {
+		So(lib.GetOr(config, "non-existent", time.Second), ShouldEqual, time.Second)
+	})
+
+	Convey("Returns the default if the key can't be converted", t, func() {
+		So(lib.GetOr(config, "timeout", 5), ShouldEqual, 5)
+	})
+}
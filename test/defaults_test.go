@@ -0,0 +1,117 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+type defaultsTLS struct {
+	Enabled bool   `mapstructure:"enabled" default:"true"`
+	Cert    string `mapstructure:"cert"`
+}
+
+type defaultsDatabase struct {
+	Host    string        `mapstructure:"host" default:"localhost"`
+	Port    uint16        `mapstructure:"port" default:"5432"`
+	Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+	TLS     *defaultsTLS  `mapstructure:"tls"`
+}
+
+type defaultsCommon struct {
+	Name string `mapstructure:"name" default:"app"`
+}
+
+type defaultsConfig struct {
+	defaultsCommon `mapstructure:",squash"`
+	Database       defaultsDatabase  `mapstructure:"db"`
+	Tags           []string          `mapstructure:"tags" default:"a, b"`
+	Weights        []float64         `mapstructure:"weights" default:"[0.5, 1.5]"`
+	Labels         map[string]string `mapstructure:"labels" default:"{\"team\": \"core\"}"`
+	Ignored        string            `mapstructure:"-" default:"ignored"`
+	Untagged       int
+	Next           *defaultsConfig `mapstructure:"next"`
+}
+
+func TestDefaultsLoader(t *testing.T) {
+
+	Convey("Reads the default tags of a structure type", t, func() {
+		loader := lib.NewDefaultsLoader((*defaultsConfig)(nil))
+		result, err := loader.Load()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, map[string]interface{}{
+			"name": "app",
+			"db": map[string]interface{}{
+				"host":    "localhost",
+				"port":    5432,
+				"timeout": 5 * time.Second,
+			},
+			"tags":    []interface{}{"a", "b"},
+			"weights": []interface{}{0.5, 1.5},
+			"labels":  map[string]interface{}{"team": "core"},
+		})
+	})
+
+	Convey("Prefers the values of a populated instance over the tags", t, func() {
+		loader := lib.NewDefaultsLoader(defaultsConfig{
+			Database: defaultsDatabase{Port: 6543, TLS: &defaultsTLS{Cert: "cert.pem"}},
+			Untagged: 3,
+		})
+		result, err := loader.Load()
+		So(err, ShouldBeNil)
+		So(result["db"], ShouldResemble, map[string]interface{}{
+			"host":    "localhost",
+			"port":    6543,
+			"timeout": 5 * time.Second,
+			"tls":     map[string]interface{}{"enabled": true, "cert": "cert.pem"},
+		})
+		So(result["Untagged"], ShouldEqual, 3)
+	})
+
+	Convey("Leaves sections behind nil pointers out", t, func() {
+		config := lib.NewConfig()
+		So(config.Use(lib.NewDefaultsLoader((*defaultsConfig)(nil))), ShouldBeNil)
+		So(config.Snapshot()["db"], ShouldNotContainKey, "tls")
+
+		structure := defaultsConfig{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.Database.TLS, ShouldBeNil)
+		So(structure.Next, ShouldBeNil)
+	})
+
+	Convey("Describes the field that supplied a key", t, func() {
+		loader := lib.NewDefaultsLoader(&defaultsConfig{})
+		_, err := loader.Load()
+		So(err, ShouldBeNil)
+		So(loader.Describe([]string{"db", "port"}), ShouldResemble, lib.Origin{Loader: "defaults", Source: "defaultsConfig.Database.Port"})
+		So(loader.Describe([]string{"name"}).Source, ShouldEqual, "defaultsConfig.defaultsCommon.Name")
+	})
+
+	Convey("Returns an error for a default that doesn't fit the field", t, func() {
+		loader := lib.NewDefaultsLoader(&struct {
+			Port int `mapstructure:"port" default:"http"`
+		}{})
+		_, err := loader.Load()
+		So(err.Error(), ShouldEqual, "key 'port': failed to cast value to integer: value has type string")
+	})
+
+	Convey("Returns an error when not given a structure", t, func() {
+		_, err := lib.NewDefaultsLoader(5).Load()
+		So(err.Error(), ShouldEqual, "defaults must be read from a structure, not int")
+	})
+
+	Convey("Supplies defaults that round trip through ToStructure", t, func() {
+		config := lib.NewConfig()
+		So(config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "db.internal"}})), ShouldBeNil)
+		So(config.Use(lib.NewDefaultsLoader((*defaultsConfig)(nil))), ShouldBeNil)
+
+		structure := defaultsConfig{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.Name, ShouldEqual, "app")
+		So(structure.Database.Host, ShouldEqual, "db.internal")
+		So(structure.Database.Port, ShouldEqual, 5432)
+		So(structure.Tags, ShouldResemble, []string{"a", "b"})
+	})
+}
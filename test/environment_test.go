@@ -1,6 +1,7 @@
 package test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -89,4 +90,81 @@ func TestParseEnvironment(t *testing.T) {
 		So(result["test"], ShouldEqual, value)
 		So(err, ShouldBeNil)
 	})
+
+	Convey("Builds slices from indexed environment variables when enabled", t, func() {
+		loader := lib.NewEnvironmentLoader(true, "__", "APP__")
+		loader.IndexedSlices = true
+
+		Convey("Builds slices of maps", func() {
+			result, err := loader.ParseEnvironment([]string{"APP__SERVERS__1__HOST=b", "APP__SERVERS__0__HOST=a", "APP__SERVERS__0__PORT=80"})
+			So(result, ShouldResemble, map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 80},
+				map[string]interface{}{"host": "b"},
+			}})
+			So(err, ShouldBeNil)
+			So(loader.Describe([]string{"servers"}).Source, ShouldEqual, "APP__SERVERS__0__HOST, APP__SERVERS__0__PORT, APP__SERVERS__1__HOST")
+		})
+
+		Convey("Builds nested slices", func() {
+			result, err := loader.ParseEnvironment([]string{"APP__MATRIX__0__0=1", "APP__MATRIX__0__1=2", "APP__MATRIX__1__0=3"})
+			So(result, ShouldResemble, map[string]interface{}{"matrix": []interface{}{[]interface{}{1, 2}, []interface{}{3}}})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Leaves maps with other keys and parsed JSON objects alone", func() {
+			result, err := loader.ParseEnvironment([]string{"APP__CODES__0=a", "APP__CODES__X=b", `APP__OBJECT={"0": "a"}`, "APP__PADDED__01=a"})
+			So(result, ShouldResemble, map[string]interface{}{
+				"codes":  map[string]interface{}{"0": "a", "x": "b"},
+				"object": map[string]interface{}{"0": "a"},
+				"padded": map[string]interface{}{"01": "a"},
+			})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error when an index is missing", func() {
+			_, err := loader.ParseEnvironment([]string{"APP__SERVERS__0__HOST=a", "APP__SERVERS__2__HOST=c"})
+			var indexError *lib.IndexError
+			So(errors.As(err, &indexError), ShouldBeTrue)
+			So(indexError.Missing, ShouldEqual, 1)
+			So(err.Error(), ShouldEqual, "key 'servers': indexes must run from 0 without gaps, index 1 is missing")
+		})
+
+		Convey("Keeps indexes as map keys when disabled", func() {
+			loader.IndexedSlices = false
+			result, err := loader.ParseEnvironment([]string{"APP__SERVERS__0__HOST=a"})
+			So(result, ShouldResemble, map[string]interface{}{"servers": map[string]interface{}{"0": map[string]interface{}{"host": "a"}}})
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Splits lists when a list separator is configured", t, func() {
+		loader := lib.NewEnvironmentLoader(true, "__", "")
+		loader.ListSeparator = ","
+
+		Convey("Parses each element of the list", func() {
+			result, err := loader.ParseEnvironment([]string{"TAGS=a, b,c", "PORTS=80,443", "NAME=single"})
+			So(result, ShouldResemble, map[string]interface{}{
+				"tags":  []interface{}{"a", "b", "c"},
+				"ports": []interface{}{80, 443},
+				"name":  "single",
+			})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Doesn't split quoted values or JSON", func() {
+			result, err := loader.ParseEnvironment([]string{`QUOTED="a,b"`, "ARRAY=[1,2]", `OBJECT={"a":1,"b":2}`})
+			So(result, ShouldResemble, map[string]interface{}{
+				"quoted": "a,b",
+				"array":  []interface{}{1.0, 2.0},
+				"object": map[string]interface{}{"a": 1.0, "b": 2.0},
+			})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Applies type hints to each element", func() {
+			loader.TypeHints = map[string]lib.TypeHint{"ports": lib.IntegerHint}
+			_, err := loader.ParseEnvironment([]string{"PORTS=80,http"})
+			So(err.Error(), ShouldEqual, "key 'ports:1': failed to cast value to integer: value has type string")
+		})
+	})
 }
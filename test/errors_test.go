@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestErrors(t *testing.T) {
+	config := lib.NewConfig()
+	config.Use(lib.NewMapLoader(map[string]interface{}{
+		"db": map[string]interface{}{
+			"port":  "not a port",
+			"hosts": []interface{}{"a", 1},
+		},
+	}))
+
+	Convey("Not found errors", t, func() {
+		_, err := config.Get("db:user:name")
+
+		Convey("Carry the full key", func() {
+			var notFoundError *lib.NotFoundError
+			So(errors.As(err, &notFoundError), ShouldBeTrue)
+			So(notFoundError.Key, ShouldEqual, "db:user:name")
+			So(err.Error(), ShouldEqual, "key 'db:user:name' was not found")
+		})
+
+		Convey("Match ErrNotFound", func() {
+			So(errors.Is(err, lib.ErrNotFound), ShouldBeTrue)
+			So(errors.Is(err, lib.ErrTypeMismatch), ShouldBeFalse)
+		})
+	})
+
+	Convey("Type errors", t, func() {
+
+		Convey("Carry the key, expected type, actual type and value", func() {
+			_, err := config.GetInteger("db:port")
+			var typeError *lib.TypeError
+			So(errors.As(err, &typeError), ShouldBeTrue)
+			So(typeError.Key, ShouldEqual, "db:port")
+			So(typeError.Expected, ShouldEqual, "integer")
+			So(typeError.Actual, ShouldEqual, "string")
+			So(typeError.Value, ShouldEqual, "not a port")
+			So(err.Error(), ShouldEqual, "key 'db:port': failed to cast value to integer: value has type string")
+		})
+
+		Convey("Carry the offending slice element", func() {
+			_, err := config.GetStringSlice("db:hosts")
+			var typeError *lib.TypeError
+			So(errors.As(err, &typeError), ShouldBeTrue)
+			So(typeError.Element, ShouldResemble, &lib.ElementError{Index: 1, Value: 1})
+			So(err.Error(), ShouldEqual, "key 'db:hosts': failed to cast value to string slice: element 1 has type int")
+		})
+
+		Convey("Are returned when walking through a value that isn't a map", func() {
+			_, err := config.Get("db:port:number")
+			var typeError *lib.TypeError
+			So(errors.As(err, &typeError), ShouldBeTrue)
+			So(typeError.Key, ShouldEqual, "db:port")
+			So(typeError.Expected, ShouldEqual, "map")
+		})
+
+		Convey("Don't carry a key when casting directly", func() {
+			_, err := lib.CastInteger("Hello")
+			So(err.Error(), ShouldEqual, "failed to cast value to integer: value has type string")
+		})
+
+		Convey("Match ErrTypeMismatch", func() {
+			_, err := lib.GetAs[bool](config, "db:port")
+			So(errors.Is(err, lib.ErrTypeMismatch), ShouldBeTrue)
+			So(err.Error(), ShouldStartWith, "key 'db:port': ")
+		})
+	})
+
+	Convey("Conflict errors", t, func() {
+
+		Convey("Carry the full key that is already present", func() {
+			_, err := lib.Set(config.Snapshot(), []string{"db", "port"}, 5432)
+			var conflictError *lib.ConflictError
+			So(errors.As(err, &conflictError), ShouldBeTrue)
+			So(conflictError.Key, ShouldEqual, "db:port")
+			So(conflictError.Existing, ShouldEqual, "not a port")
+		})
+
+		Convey("Carry the parent key that is in the way", func() {
+			_, err := lib.Set(map[string]interface{}{"a": map[string]interface{}{"b": 1}}, []string{"a", "b", "c"}, 2)
+			var conflictError *lib.ConflictError
+			So(errors.As(err, &conflictError), ShouldBeTrue)
+			So(conflictError.Key, ShouldEqual, "a:b")
+		})
+
+		Convey("Match ErrConflict", func() {
+			_, err := lib.Set(config.Snapshot(), []string{"db", "port"}, 5432)
+			So(errors.Is(err, lib.ErrConflict), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestInference(t *testing.T) {
+
+	Convey("InferAggressive", t, func() {
+
+		Convey("Parses values like ParseString", func() {
+			So(lib.InferAggressive.Parse("01234"), ShouldEqual, 1234)
+			So(lib.InferAggressive.Parse("T"), ShouldEqual, true)
+			So(lib.InferAggressive.Parse("3s"), ShouldEqual, 3*time.Second)
+		})
+	})
+
+	Convey("InferStrings", t, func() {
+
+		Convey("Keeps every value as a string", func() {
+			So(lib.InferStrings.Parse("01234"), ShouldEqual, "01234")
+			So(lib.InferStrings.Parse("true"), ShouldEqual, "true")
+			So(lib.InferStrings.Parse("[1, 2]"), ShouldEqual, "[1, 2]")
+		})
+	})
+
+	Convey("InferJSON", t, func() {
+
+		Convey("Parses JSON literals", func() {
+			So(lib.InferJSON.Parse("1234"), ShouldEqual, 1234)
+			So(lib.InferJSON.Parse("1.5"), ShouldEqual, 1.5)
+			So(lib.InferJSON.Parse("true"), ShouldEqual, true)
+			So(lib.InferJSON.Parse("[1, 2]"), ShouldResemble, []interface{}{float64(1), float64(2)})
+			So(lib.InferJSON.Parse(`{"a": "b"}`), ShouldResemble, map[string]interface{}{"a": "b"})
+		})
+
+		Convey("Keeps anything that isn't a JSON literal as a string", func() {
+			So(lib.InferJSON.Parse("01234"), ShouldEqual, "01234")
+			So(lib.InferJSON.Parse("T"), ShouldEqual, "T")
+			So(lib.InferJSON.Parse("f"), ShouldEqual, "f")
+			So(lib.InferJSON.Parse("3s"), ShouldEqual, "3s")
+		})
+	})
+
+	Convey("Quoted values are always kept as strings", t, func() {
+		for _, inference := range []lib.Inference{lib.InferAggressive, lib.InferStrings, lib.InferJSON} {
+			So(inference.Parse(`"01234"`), ShouldEqual, "01234")
+			So(inference.Parse(`'true'`), ShouldEqual, "true")
+		}
+	})
+
+	Convey("Values with mismatched quotes aren't unquoted", t, func() {
+		So(lib.InferStrings.Parse(`"abc'`), ShouldEqual, `"abc'`)
+		So(lib.InferStrings.Parse(`"`), ShouldEqual, `"`)
+	})
+}
+
+func TestTypeHint(t *testing.T) {
+
+	Convey("Parses values into the hinted type", t, func() {
+		cases := []struct {
+			hint     lib.TypeHint
+			value    string
+			expected interface{}
+		}{
+			{lib.StringHint, "01234", "01234"},
+			{lib.IntegerHint, "01234", 1234},
+			{lib.FloatHint, "1e3", float64(1000)},
+			{lib.BooleanHint, "T", true},
+			{lib.DurationHint, "3s", 3 * time.Second},
+			{lib.JSONHint, `{"a": 1}`, map[string]interface{}{"a": float64(1)}},
+		}
+		for _, c := range cases {
+			result, err := c.hint.Parse(c.value)
+			So(result, ShouldResemble, c.expected)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("Returns a type error if the value can't be parsed", t, func() {
+		result, err := lib.IntegerHint.Parse("abc")
+		So(result, ShouldBeNil)
+		So(errors.Is(err, lib.ErrTypeMismatch), ShouldBeTrue)
+	})
+}
+
+func TestLoaderInference(t *testing.T) {
+
+	Convey("The environment loader", t, func() {
+
+		Convey("Uses the configured inference policy", func() {
+			loader := lib.NewEnvironmentLoader(true, "__", "")
+			loader.Inference = lib.InferStrings
+			result, err := loader.ParseEnvironment([]string{"ZIP=01234", "ENABLED=true"})
+			So(result, ShouldResemble, map[string]interface{}{"zip": "01234", "enabled": "true"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Uses the type hints of specific keys", func() {
+			loader := lib.NewEnvironmentLoader(true, "__", "")
+			loader.TypeHints = map[string]lib.TypeHint{"address:zip": lib.StringHint}
+			result, err := loader.ParseEnvironment([]string{"ADDRESS__ZIP=01234", "ADDRESS__NUMBER=01"})
+			So(result, ShouldResemble, map[string]interface{}{"address": map[string]interface{}{"zip": "01234", "number": 1}})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error naming the key if a hinted value can't be parsed", func() {
+			loader := lib.NewEnvironmentLoader(true, "", "")
+			loader.TypeHints = map[string]lib.TypeHint{"port": lib.IntegerHint}
+			_, err := loader.ParseEnvironment([]string{"PORT=abc"})
+			var typeError *lib.TypeError
+			So(errors.As(err, &typeError), ShouldBeTrue)
+			So(typeError.Key, ShouldEqual, "port")
+		})
+	})
+
+	Convey("The argument loader", t, func() {
+
+		Convey("Uses the configured inference policy", func() {
+			loader := lib.NewArgumentLoader("", "")
+			loader.Inference = lib.InferJSON
+			result, err := loader.ParseArguments([]string{"--zip=01234", "--port", "8080", "--verbose"})
+			So(result, ShouldResemble, map[string]interface{}{"zip": "01234", "port": 8080, "verbose": true})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Uses the type hints of specific keys", func() {
+			loader := lib.NewArgumentLoader("", "")
+			loader.TypeHints = map[string]lib.TypeHint{"zip": lib.StringHint}
+			result, err := loader.ParseArguments([]string{"--zip", "01234"})
+			So(result, ShouldResemble, map[string]interface{}{"zip": "01234"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Keeps quoted values as strings", func() {
+			loader := lib.NewArgumentLoader("", "")
+			result, err := loader.ParseArguments([]string{`--zip="01234"`})
+			So(result, ShouldResemble, map[string]interface{}{"zip": "01234"})
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -22,13 +23,13 @@ func TestJSONFileLoad(t *testing.T) {
 		So(result, ShouldResemble, map[string]interface{}{
 			"string":  "woohoo",
 			"boolean": true,
-			"integer": 10.0,
+			"integer": 10,
 			"float":   3.5,
-			"array":   []interface{}{"woohoo", true, float64(10), 3.5},
+			"array":   []interface{}{"woohoo", true, 10, 3.5},
 			"object": map[string]interface{}{
 				"string":  "woohoo",
 				"boolean": true,
-				"integer": float64(10),
+				"integer": 10,
 				"float":   3.5,
 			},
 		})
@@ -58,6 +59,30 @@ func TestParseJSON(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Reads integral numbers as integers and other numbers as floats", func() {
+			result, err := loader.ParseJSON([]byte(`{"a": 1, "b": 1.0, "c": 1e3, "d": [2, 2.5]}`))
+			So(result, ShouldResemble, map[string]interface{}{"a": 1, "b": 1.0, "c": 1000.0, "d": []interface{}{2, 2.5}})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Reads large integers without losing precision", func() {
+			result, err := loader.ParseJSON([]byte(`{"id": 9007199254740993, "min": -9223372036854775808}`))
+			So(result, ShouldResemble, map[string]interface{}{"id": 9007199254740993, "min": math.MinInt64})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error when an integer doesn't fit in 64 bits", func() {
+			result, err := loader.ParseJSON([]byte(`{"a": {"b": [1, 9223372036854775808]}}`))
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "a:b:1")
+		})
+
+		Convey("Returns an error when there is data after the object", func() {
+			result, err := loader.ParseJSON([]byte(`{"a": 1} {"b": 2}`))
+			So(result, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+
 		Convey("Returns the original map when duration parsing is disabled", func() {
 			result, err := loader.ParseJSON([]byte(`{"a": "3s"}`))
 			So(result, ShouldResemble, map[string]interface{}{"a": "3s"})
@@ -85,4 +110,93 @@ func TestParseDurationStrings(t *testing.T) {
 		m := loader.ParseDurationStrings(map[string]interface{}{"a": map[string]interface{}{"b": "3s"}})
 		So(m, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": 3 * time.Second}})
 	})
+
+	Convey("Recurses into arrays and arrays of maps", t, func() {
+		m := loader.ParseDurationStrings(map[string]interface{}{
+			"backoff": []interface{}{"1s", "5s", 30},
+			"servers": []interface{}{map[string]interface{}{"timeout": "3s"}},
+		})
+		So(m, ShouldResemble, map[string]interface{}{
+			"backoff": []interface{}{1 * time.Second, 5 * time.Second, 30},
+			"servers": []interface{}{map[string]interface{}{"timeout": 3 * time.Second}},
+		})
+	})
+
+	Convey("Only parses the configured key paths", t, func() {
+		l := lib.NewJSONFileLoader("", true)
+		l.DurationKeys = []string{"backoff", "servers:timeout"}
+		m := l.ParseDurationStrings(map[string]interface{}{
+			"backoff": []interface{}{"1s", "5s"},
+			"servers": []interface{}{map[string]interface{}{"timeout": "3s", "version": "1h"}},
+			"version": "1h",
+		})
+		So(m, ShouldResemble, map[string]interface{}{
+			"backoff": []interface{}{1 * time.Second, 5 * time.Second},
+			"servers": []interface{}{map[string]interface{}{"timeout": 3 * time.Second, "version": "1h"}},
+			"version": "1h",
+		})
+	})
+
+	Convey("Only parses keys with the configured suffix", t, func() {
+		l := lib.NewJSONFileLoader("", true)
+		l.DurationSuffix = "Timeout"
+		m := l.ParseDurationStrings(map[string]interface{}{
+			"readTimeout": "3s",
+			"http":        map[string]interface{}{"writeTimeout": []interface{}{"1s"}},
+			"version":     "1h",
+		})
+		So(m, ShouldResemble, map[string]interface{}{
+			"readTimeout": 3 * time.Second,
+			"http":        map[string]interface{}{"writeTimeout": []interface{}{1 * time.Second}},
+			"version":     "1h",
+		})
+	})
+}
+
+func TestJSONFileSlices(t *testing.T) {
+
+	Convey("Reads JSON arrays with the slice getters", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewJSONFileLoader("test.json", false))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"strings": lib.ParseString(`["a", "b"]`)}))
+
+		strings, err := config.GetStringSlice("strings")
+		So(strings, ShouldResemble, []string{"a", "b"})
+		So(err, ShouldBeNil)
+
+		mixed, err := config.GetStringSlice("array")
+		So(mixed, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestJSONFileIntegers(t *testing.T) {
+
+	Convey("Reads large JSON integers with the getters and into structures", t, func() {
+		config := lib.NewConfig()
+		loader := lib.NewJSONFileLoader("", false)
+		m, err := loader.ParseJSON([]byte(`{"id": 9007199254740993, "ids": [9007199254740993], "ratio": 2}`))
+		So(err, ShouldBeNil)
+		config.Use(lib.NewMapLoader(m))
+
+		id, err := config.GetInteger("id")
+		So(id, ShouldEqual, 9007199254740993)
+		So(err, ShouldBeNil)
+
+		ids, err := config.GetIntegerSlice("ids")
+		So(ids, ShouldResemble, []int{9007199254740993})
+		So(err, ShouldBeNil)
+
+		ratio, err := config.GetFloat("ratio")
+		So(ratio, ShouldEqual, 2.0)
+		So(err, ShouldBeNil)
+
+		structure := struct {
+			ID    int64
+			Ratio float64
+		}{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.ID, ShouldEqual, int64(9007199254740993))
+		So(structure.Ratio, ShouldEqual, 2.0)
+	})
 }
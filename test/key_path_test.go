@@ -0,0 +1,156 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestKeyPaths(t *testing.T) {
+	newConfig := func() *lib.Config {
+		config := lib.NewConfig()
+		config.Use(lib.NewJSONFileLoader("test.json", false))
+		config.Use(lib.NewMapLoader(map[string]interface{}{
+			"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 80},
+				map[string]interface{}{"host": "b", "port": 81},
+			},
+			"urn:x": map[string]interface{}{"y": "z"},
+		}))
+		return config
+	}
+
+	Convey("Gets the elements of slices by index", t, func() {
+		config := newConfig()
+		host, err := config.GetString("servers:1:host")
+		So(host, ShouldEqual, "b")
+		So(err, ShouldBeNil)
+
+		value, err := config.GetFloat("array:3")
+		So(value, ShouldEqual, 3.5)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Sets and deletes the elements of slices by index", t, func() {
+		config := newConfig()
+		So(config.Set("servers:0:host", "c"), ShouldBeNil)
+		So(config.Set("servers:2", map[string]interface{}{"host": "d"}), ShouldBeNil)
+		So(config.Delete("servers:1"), ShouldBeNil)
+
+		So(config.Snapshot()["servers"], ShouldResemble, []interface{}{
+			map[string]interface{}{"host": "c", "port": 80},
+			map[string]interface{}{"host": "d"},
+		})
+
+		explanation, err := config.Explain("servers:0:host")
+		So(err, ShouldBeNil)
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "set", Value: "c"})
+	})
+
+	Convey("Returns an error when setting keys that don't index into a slice", t, func() {
+		config := newConfig()
+		for _, key := range []string{"servers:5:host", "servers:-1", "servers:host"} {
+			So(config.Set(key, "x"), ShouldNotBeNil)
+		}
+
+		servers, err := config.GetSlice("servers")
+		So(err, ShouldBeNil)
+		So(servers, ShouldHaveLength, 2)
+	})
+
+	Convey("Skips overrides that no longer fit after reloading", t, func() {
+		loader := lib.NewMapLoader(map[string]interface{}{"servers": []interface{}{"a", "b", "c"}})
+		config := lib.NewConfig()
+		config.Use(loader)
+		So(config.Set("servers:2", "d"), ShouldBeNil)
+
+		loader.Map = map[string]interface{}{"servers": []interface{}{"a"}}
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"servers": []interface{}{"a"}})
+	})
+
+	Convey("Explains values inside slices with the origin of the slice", t, func() {
+		config := newConfig()
+		explanation, err := config.Explain("array:0")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "array:0")
+		So(explanation.Origin.Source, ShouldEqual, "test.json")
+	})
+
+	Convey("Gets sub-configs of slice elements", t, func() {
+		config := newConfig()
+		subConfig, err := config.GetSubConfig("servers:0")
+		So(err, ShouldBeNil)
+		So(subConfig.Snapshot(), ShouldResemble, map[string]interface{}{"host": "a", "port": 80})
+
+		subConfig, err = config.GetSubConfigWithMode("servers:1", lib.WriteThrough)
+		So(err, ShouldBeNil)
+		So(subConfig.Set("port", 8080), ShouldBeNil)
+		port, _ := config.GetInteger("servers:1:port")
+		So(port, ShouldEqual, 8080)
+	})
+
+	Convey("Reaches keys containing the delimiter by escaping it", t, func() {
+		config := newConfig()
+		value, err := config.GetString(`urn\:x:y`)
+		So(value, ShouldEqual, "z")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Uses a custom delimiter", t, func() {
+		config := newConfig()
+		config.SetDelimiter(".")
+
+		value, err := config.GetString("urn:x.y")
+		So(value, ShouldEqual, "z")
+		So(err, ShouldBeNil)
+
+		So(config.Set("object.nested", true), ShouldBeNil)
+		nested, _ := config.GetBoolean("object.nested")
+		So(nested, ShouldBeTrue)
+
+		subConfig, err := config.GetSubConfigWithMode("servers.0", lib.WriteThrough)
+		So(err, ShouldBeNil)
+		So(subConfig.Set("tls.enabled", true), ShouldBeNil)
+		enabled, _ := config.GetBoolean("servers.0.tls.enabled")
+		So(enabled, ShouldBeTrue)
+
+		explanation, err := subConfig.Explain("tls.enabled")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "tls.enabled")
+	})
+
+	Convey("Reports keys with a custom delimiter", t, func() {
+		config := newConfig()
+		config.SetDelimiter(".")
+
+		_, err := config.Get("urn:x.missing")
+		So(err.Error(), ShouldEqual, "key 'urn:x.missing' was not found")
+
+		_, err = config.Get("servers.0.host.name")
+		So(err.Error(), ShouldStartWith, "key 'servers.0.host': ")
+
+		err = config.Set("servers.5", "x")
+		So(err.Error(), ShouldEqual, "key 'servers.5': index is out of range for a slice of length 2")
+
+		explanation, err := config.Explain("urn:x.y")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "urn:x.y")
+
+		err = config.ToStructure(&struct {
+			Servers []struct {
+				Name string `mapstructure:"name" validate:"required"`
+			} `mapstructure:"servers"`
+		}{})
+		So(err.Error(), ShouldEqual, "validation failed: key 'servers.0.name' is required; key 'servers.1.name' is required")
+	})
+
+	Convey("Reports conflicts with a custom delimiter", t, func() {
+		config := lib.NewConfig()
+		config.SetDelimiter(".")
+		config.Use(lib.NewMapLoader(map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"a": map[string]interface{}{"b": 2}}))
+		So(config.Conflicts()[0].Key, ShouldEqual, "a.b")
+	})
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestUseLayer(t *testing.T) {
+
+	Convey("Layers with a higher priority win", t, func() {
+		config := lib.NewConfig()
+		config.UseLayer("defaults", -10, lib.NewMapLoader(map[string]interface{}{"host": "default", "port": 80}))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"host": "file"}))
+		config.UseLayer("remote", 10, lib.NewMapLoader(map[string]interface{}{"host": "remote"}))
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"host": "remote", "port": 80})
+
+		explanation, err := config.Explain("host")
+		So(err, ShouldBeNil)
+		So(explanation.Shadowed, ShouldResemble, []lib.Origin{{Loader: "map", Value: "file"}, {Loader: "map", Value: "default"}})
+	})
+
+	Convey("Layers with the same priority win in the order they were added", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"host": "first"}))
+		config.UseLayer("second", 0, lib.NewMapLoader(map[string]interface{}{"host": "second"}))
+
+		host, _ := config.GetString("host")
+		So(host, ShouldEqual, "first")
+	})
+
+	Convey("Replaces a layer with the same name", t, func() {
+		config := lib.NewConfig()
+		config.UseLayer("remote", 10, lib.NewMapLoader(map[string]interface{}{"host": "old", "port": 80}))
+		config.UseLayer("remote", 10, lib.NewMapLoader(map[string]interface{}{"host": "new"}))
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"host": "new"})
+		So(config.Layers(), ShouldHaveLength, 1)
+	})
+
+	Convey("Keeps the existing layer if the replacement fails to load", t, func() {
+		config := lib.NewConfig()
+		config.UseLayer("file", 0, lib.NewMapLoader(map[string]interface{}{"host": "old"}))
+		err := config.UseLayer("file", 0, lib.NewJSONFileLoader("", false))
+
+		So(err, ShouldNotBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"host": "old"})
+	})
+
+	Convey("Keeps overrides on top of every layer", t, func() {
+		config := lib.NewConfig()
+		config.Set("host", "set")
+		config.UseLayer("remote", 100, lib.NewMapLoader(map[string]interface{}{"host": "remote"}))
+
+		host, _ := config.GetString("host")
+		So(host, ShouldEqual, "set")
+	})
+}
+
+func TestRemoveLayer(t *testing.T) {
+
+	Convey("Removes a layer and recomputes the configuration", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"host": "file"}))
+		config.UseLayer("remote", 10, lib.NewMapLoader(map[string]interface{}{"host": "remote", "port": 80}))
+
+		So(config.RemoveLayer("remote"), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"host": "file"})
+	})
+
+	Convey("Returns an error if there is no layer with the name", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"host": "file"}))
+
+		So(config.RemoveLayer("remote"), ShouldNotBeNil)
+		So(config.RemoveLayer(""), ShouldNotBeNil)
+	})
+}
+
+func TestLayers(t *testing.T) {
+
+	Convey("Lists every layer from the highest priority to the lowest", t, func() {
+		fileLoader := lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "file", "port": 80}})
+		remoteLoader := lib.NewMapLoader(map[string]interface{}{"host": "remote"})
+
+		config := lib.NewConfig()
+		config.Use(fileLoader)
+		config.UseLayer("remote", 10, remoteLoader)
+
+		So(config.Layers(), ShouldResemble, []lib.Layer{
+			{Name: "remote", Priority: 10, Loader: remoteLoader, Keys: 1},
+			{Name: "", Priority: 0, Loader: fileLoader, Keys: 2},
+		})
+	})
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestKeyNormalization(t *testing.T) {
+
+	Convey("Normalize", t, func() {
+
+		Convey("Keeps keys as they are by default", func() {
+			So(lib.NormalizeNone.Normalize("MAX_CONNS"), ShouldEqual, "MAX_CONNS")
+		})
+
+		Convey("Ignores case", func() {
+			So(lib.NormalizeCase.Normalize("MaxConns"), ShouldEqual, "maxconns")
+			So(lib.NormalizeCase.Normalize("MAX_CONNS"), ShouldEqual, "max_conns")
+		})
+
+		Convey("Folds snake_case, kebab-case and camelCase together", func() {
+			So(lib.NormalizeWords.Normalize("MAX_CONNS"), ShouldEqual, "maxconns")
+			So(lib.NormalizeWords.Normalize("max-conns"), ShouldEqual, "maxconns")
+			So(lib.NormalizeWords.Normalize("maxConns"), ShouldEqual, "maxconns")
+		})
+	})
+
+	Convey("NormalizeMap", t, func() {
+
+		Convey("Normalizes nested keys, including the maps inside slices", func() {
+			result := lib.NormalizeWords.NormalizeMap(map[string]interface{}{
+				"DB":      map[string]interface{}{"MAX_CONNS": 1},
+				"servers": []interface{}{map[string]interface{}{"Host-Name": "a"}},
+			})
+			So(result, ShouldResemble, map[string]interface{}{
+				"db":      map[string]interface{}{"maxconns": 1},
+				"servers": []interface{}{map[string]interface{}{"hostname": "a"}},
+			})
+		})
+
+		Convey("Merges maps whose keys end up the same", func() {
+			result := lib.NormalizeCase.NormalizeMap(map[string]interface{}{
+				"DB": map[string]interface{}{"host": "a"},
+				"db": map[string]interface{}{"port": 1},
+				"A":  1,
+				"a":  2,
+			})
+			So(result, ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": "a", "port": 1}, "a": 1})
+		})
+	})
+}
+
+func TestConfigKeyNormalization(t *testing.T) {
+	newConfig := func() *lib.Config {
+		environment := lib.NewEnvironmentLoader(false, "__", "")
+		environmentMap, _ := environment.ParseEnvironment([]string{"DB__MAX_CONNS=20"})
+
+		config := lib.NewConfig()
+		config.SetKeyNormalization(lib.NormalizeWords)
+		config.Use(lib.NewMapLoader(environmentMap))
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"maxConns": 10, "host-name": "localhost"}}))
+		return config
+	}
+
+	Convey("Merges keys from different loaders into one tree", t, func() {
+		config := newConfig()
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db": map[string]interface{}{"maxconns": 20, "hostname": "localhost"},
+		})
+		So(config.Conflicts(), ShouldBeEmpty)
+
+		explanation, err := config.Explain("DB:maxConns")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "db:maxconns")
+		So(explanation.Shadowed, ShouldResemble, []lib.Origin{{Loader: "map", Value: 10}})
+	})
+
+	Convey("Gets values with any form of the key", t, func() {
+		config := newConfig()
+		for _, key := range []string{"db:maxConns", "DB:MAX_CONNS", "db:max-conns"} {
+			value, err := config.GetInteger(key)
+			So(value, ShouldEqual, 20)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("Sets and deletes values with any form of the key", t, func() {
+		config := newConfig()
+		So(config.Set("DB:MAX_CONNS", 30), ShouldBeNil)
+		So(config.Set("db", map[string]interface{}{"Time-Out": 5}), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"timeout": 5}})
+
+		So(config.Unset("Db"), ShouldBeNil)
+		So(config.Delete("db:hostName"), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"maxconns": 20}})
+	})
+
+	Convey("Subscribes with any form of the key", t, func() {
+		config := newConfig()
+		var newValue interface{}
+		config.OnChange("db:max_conns", func(_ interface{}, value interface{}) { newValue = value })
+		config.Set("db:maxConns", 30)
+		So(newValue, ShouldEqual, 30)
+	})
+
+	Convey("Gets sub-configs with any form of the key", t, func() {
+		config := newConfig()
+		subConfig, err := config.GetSubConfigWithMode("DB", lib.WriteThrough)
+		So(err, ShouldBeNil)
+
+		value, err := subConfig.GetInteger("maxConns")
+		So(value, ShouldEqual, 20)
+		So(err, ShouldBeNil)
+
+		subConfig, err = config.GetSubConfig("DB")
+		So(err, ShouldBeNil)
+		value, err = subConfig.GetInteger("MAX_CONNS")
+		So(value, ShouldEqual, 20)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Maps normalized keys to the fields of a structure", t, func() {
+		type Pool struct {
+			MaxConns int
+		}
+		type Server struct {
+			HostName string `mapstructure:"host_name"`
+		}
+		structure := struct {
+			DB struct {
+				Pool     `mapstructure:",squash"`
+				HostName string `mapstructure:"host-name"`
+			}
+			Servers []Server
+			Tags    map[string]*Server
+		}{}
+
+		config := newConfig()
+		config.Set("servers", []interface{}{map[string]interface{}{"hostName": "a"}})
+		config.Set("tags:first:HOST_NAME", "b")
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.DB.MaxConns, ShouldEqual, 20)
+		So(structure.DB.HostName, ShouldEqual, "localhost")
+		So(structure.Servers, ShouldResemble, []Server{{HostName: "a"}})
+		So(structure.Tags["first"].HostName, ShouldEqual, "b")
+	})
+
+	Convey("Keeps the keys of maps as they were loaded or set", t, func() {
+		type Client struct {
+			Headers map[string]string
+			Extra   map[string]interface{}
+		}
+		config := newConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{
+			"client": map[string]interface{}{
+				"headers": map[string]interface{}{"X-Request-Id": "a", "Content_Type": "json"},
+				"extra":   map[string]interface{}{"Nested_Map": map[string]interface{}{"Inner-Key": 1}},
+			},
+		}))
+		config.Set("CLIENT:HEADERS:Accept-Encoding", "gzip")
+
+		structure := struct{ Client Client }{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.Client.Headers, ShouldResemble, map[string]string{
+			"X-Request-Id": "a", "Content_Type": "json", "Accept-Encoding": "gzip",
+		})
+		So(structure.Client.Extra, ShouldResemble, map[string]interface{}{
+			"Nested_Map": map[string]interface{}{"Inner-Key": 1},
+		})
+
+		client := Client{}
+		subConfig, err := config.GetSubConfig("client")
+		So(err, ShouldBeNil)
+		So(subConfig.Reload(), ShouldBeNil)
+		So(subConfig.ToStructure(&client), ShouldBeNil)
+		So(client, ShouldResemble, structure.Client)
+
+		client = Client{}
+		subConfig, err = config.GetSubConfigWithMode("client", lib.WriteThrough)
+		So(err, ShouldBeNil)
+		So(subConfig.ToStructure(&client), ShouldBeNil)
+		So(client, ShouldResemble, structure.Client)
+	})
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+type plainLoader struct{}
+
+func (loader *plainLoader) Load() (map[string]interface{}, error) {
+	return map[string]interface{}{"one": 1}, nil
+}
+
+func TestDescribe(t *testing.T) {
+
+	Convey("Uses the loader type for loaders that can't describe themselves", t, func() {
+		origin := lib.Describe(&plainLoader{}, []string{"one"})
+		So(origin, ShouldResemble, lib.Origin{Loader: "*test.plainLoader"})
+	})
+
+	Convey("Describes environment variables", t, func() {
+		loader := lib.NewEnvironmentLoader(true, "__", "APP_")
+		_, err := loader.ParseEnvironment([]string{"APP_DB__HOST=localhost", `APP_OBJECT={"key": "value"}`})
+		So(err, ShouldBeNil)
+		So(loader.Describe([]string{"db", "host"}), ShouldResemble, lib.Origin{Loader: "environment", Source: "APP_DB__HOST"})
+		So(loader.Describe([]string{"object", "key"}), ShouldResemble, lib.Origin{Loader: "environment", Source: "APP_OBJECT"})
+	})
+
+	Convey("Describes arguments", t, func() {
+		loader := lib.NewArgumentLoader("__", "")
+		_, err := loader.ParseArguments([]string{"--db__host=localhost"})
+		So(err, ShouldBeNil)
+		So(loader.Describe([]string{"db", "host"}), ShouldResemble, lib.Origin{Loader: "arguments", Source: "--db__host=localhost"})
+	})
+
+	Convey("Describes files", t, func() {
+		So(lib.NewJSONFileLoader("test.json", false).Describe([]string{"a"}), ShouldResemble, lib.Origin{Loader: "JSON file", Source: "test.json"})
+		So(lib.NewYAMLFileLoader("test.yaml", false).Describe([]string{"a"}), ShouldResemble, lib.Origin{Loader: "YAML file", Source: "test.yaml"})
+		So(lib.NewTOMLFileLoader("test.toml").Describe([]string{"a"}), ShouldResemble, lib.Origin{Loader: "TOML file", Source: "test.toml"})
+	})
+}
+
+func TestOrigins(t *testing.T) {
+
+	Convey("Records the origin of every leaf value", t, func() {
+		origins := lib.Origins(lib.NewMapLoader(nil), map[string]interface{}{
+			"one": 1,
+			"map": map[string]interface{}{"two": 2},
+		})
+		So(origins, ShouldResemble, map[string]lib.Origin{
+			"one":     {Loader: "map", Value: 1},
+			"map:two": {Loader: "map", Value: 2},
+		})
+	})
+
+	Convey("Keeps the origins recorded when loading after a failed reload", t, func() {
+		os.Setenv("APPX_A", "1")
+		defer os.Unsetenv("APPX_A")
+
+		failing := &failingLoader{}
+		config := lib.NewConfig()
+		So(config.Use(lib.NewEnvironmentLoader(false, "", "APPX_")), ShouldBeNil)
+		So(config.Use(failing), ShouldBeNil)
+
+		// The environment loader is loaded again, forgetting APPX_A, before the reload fails
+		os.Unsetenv("APPX_A")
+		failing.fail = true
+		So(config.Reload(), ShouldNotBeNil)
+		So(config.Set("b", 2), ShouldBeNil)
+
+		explanation, err := config.Explain("A")
+		So(err, ShouldBeNil)
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "environment", Source: "APPX_A", Value: 1})
+		So(config.Layers()[0].Keys, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func newOverrideConfig() *lib.Config {
+	config := lib.NewConfig()
+	config.Use(lib.NewMapLoader(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"debug": false,
+	}))
+	return config
+}
+
+func TestConfigSet(t *testing.T) {
+
+	Convey("Overrides loaded values", t, func() {
+		config := newOverrideConfig()
+		So(config.Set("db:port", 6543), ShouldBeNil)
+		So(config.Set("debug", true), ShouldBeNil)
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost", "port": 6543},
+			"debug": true,
+		})
+	})
+
+	Convey("Replaces loaded values that are in the way", t, func() {
+		config := newOverrideConfig()
+		So(config.Set("debug:level", "verbose"), ShouldBeNil)
+
+		level, err := config.GetString("debug:level")
+		So(level, ShouldEqual, "verbose")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Overrides values from loaders used afterwards", t, func() {
+		config := lib.NewConfig()
+		config.Set("db:host", "test-host")
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+
+		host, _ := config.GetString("db:host")
+		So(host, ShouldEqual, "test-host")
+	})
+
+	Convey("Replaces earlier overrides under the same key", t, func() {
+		config := newOverrideConfig()
+		config.Set("db:host", "first")
+		config.Set("db", map[string]interface{}{"name": "app"})
+
+		So(config.Snapshot()["db"], ShouldResemble, map[string]interface{}{"name": "app"})
+	})
+
+	Convey("Keeps overrides when reloading", t, func() {
+		defaults := map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}}
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(defaults))
+		config.Set("db:port", 6543)
+
+		defaults["db"].(map[string]interface{})["host"] = "db.internal"
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot()["db"], ShouldResemble, map[string]interface{}{"host": "db.internal", "port": 6543})
+	})
+
+	Convey("Records the override as the origin, shadowing the loaded value", t, func() {
+		config := newOverrideConfig()
+		config.Set("db:port", 6543)
+
+		explanation, err := config.Explain("db:port")
+		So(err, ShouldBeNil)
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "set", Value: 6543})
+		So(explanation.Shadowed, ShouldResemble, []lib.Origin{{Loader: "map", Value: 5432}})
+	})
+}
+
+func TestDeleteAndUnset(t *testing.T) {
+
+	Convey("Deletes leaf keys", t, func() {
+		config := newOverrideConfig()
+		So(config.Delete("db:port"), ShouldBeNil)
+		So(config.Snapshot()["db"], ShouldResemble, map[string]interface{}{"host": "localhost"})
+
+		_, err := config.Explain("db:port")
+		So(errors.Is(err, lib.ErrNotFound), ShouldBeTrue)
+	})
+
+	Convey("Deletes subtrees", t, func() {
+		config := newOverrideConfig()
+		So(config.Delete("db"), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"debug": false})
+	})
+
+	Convey("Keeps keys deleted when reloading", t, func() {
+		config := newOverrideConfig()
+		config.Delete("db")
+		So(config.Reload(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"debug": false})
+	})
+
+	Convey("Returns an error when deleting a key that doesn't exist", t, func() {
+		config := newOverrideConfig()
+		err := config.Delete("db:user")
+		So(errors.Is(err, lib.ErrNotFound), ShouldBeTrue)
+	})
+
+	Convey("Unset lets the loaded values show through again", t, func() {
+		config := newOverrideConfig()
+		config.Set("db:port", 6543)
+		config.Delete("db:host")
+		config.Set("debug", true)
+
+		So(config.Unset("db"), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+			"debug": true,
+		})
+	})
+
+	Convey("ClearOverrides removes every override", t, func() {
+		config := newOverrideConfig()
+		config.Set("db:port", 6543)
+		config.Set("name", "app")
+		config.Delete("debug")
+
+		So(config.ClearOverrides(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+			"debug": false,
+		})
+	})
+
+	Convey("Write-through sub-configs only clear their own overrides", t, func() {
+		config := newOverrideConfig()
+		config.Set("debug", true)
+		subConfig, _ := config.GetSubConfigWithMode("db", lib.WriteThrough)
+		subConfig.Set("port", 6543)
+		subConfig.Delete("host")
+
+		So(subConfig.ClearOverrides(), ShouldBeNil)
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+			"debug": true,
+		})
+	})
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestDetachedSubConfig(t *testing.T) {
+
+	Convey("Setting a value on a detached sub-config doesn't affect the parent", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+
+		subConfig, err := config.GetSubConfigWithMode("db", lib.Detached)
+		So(err, ShouldBeNil)
+		So(subConfig.Set("port", 5432), ShouldBeNil)
+
+		So(subConfig.Snapshot(), ShouldResemble, map[string]interface{}{"host": "localhost", "port": 5432})
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+	})
+
+	Convey("Setting a value on the parent doesn't affect a detached sub-config", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}))
+
+		subConfig, err := config.GetSubConfig("db")
+		So(err, ShouldBeNil)
+		So(config.Set("db:port", 5432), ShouldBeNil)
+
+		So(subConfig.Snapshot(), ShouldResemble, map[string]interface{}{"host": "localhost"})
+	})
+}
+
+func TestWriteThroughSubConfig(t *testing.T) {
+	newConfig := func() *lib.Config {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{
+			"db": map[string]interface{}{"host": "localhost", "pool": map[string]interface{}{"size": 5}},
+		}))
+		return config
+	}
+
+	Convey("Reads the parent's current values", t, func() {
+		config := newConfig()
+		subConfig, err := config.GetSubConfigWithMode("db", lib.WriteThrough)
+		So(err, ShouldBeNil)
+
+		config.Set("db:port", 5432)
+		port, err := subConfig.GetInteger("port")
+		So(port, ShouldEqual, 5432)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Sets values in the parent", t, func() {
+		config := newConfig()
+		subConfig, _ := config.GetSubConfigWithMode("db", lib.WriteThrough)
+
+		So(subConfig.Set("user", "admin"), ShouldBeNil)
+		user, err := config.GetString("db:user")
+		So(user, ShouldEqual, "admin")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Adds loaders to the parent under its key", t, func() {
+		config := newConfig()
+		subConfig, _ := config.GetSubConfigWithMode("db", lib.WriteThrough)
+
+		So(subConfig.Use(lib.NewMapLoader(map[string]interface{}{"host": "ignored", "name": "app"})), ShouldBeNil)
+		So(config.Snapshot()["db"], ShouldResemble, map[string]interface{}{
+			"host": "localhost",
+			"name": "app",
+			"pool": map[string]interface{}{"size": 5},
+		})
+
+		explanation, err := subConfig.Explain("name")
+		So(err, ShouldBeNil)
+		So(explanation.Key, ShouldEqual, "name")
+		So(explanation.Origin, ShouldResemble, lib.Origin{Loader: "map", Value: "app"})
+	})
+
+	Convey("Nested write-through sub-configs refer to the same parent", t, func() {
+		config := newConfig()
+		subConfig, _ := config.GetSubConfigWithMode("db", lib.WriteThrough)
+		poolConfig, err := subConfig.GetSubConfigWithMode("pool", lib.WriteThrough)
+		So(err, ShouldBeNil)
+
+		So(poolConfig.Set("timeout", 10), ShouldBeNil)
+		timeout, err := config.GetInteger("db:pool:timeout")
+		So(timeout, ShouldEqual, 10)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Subscribes to changes in the parent", t, func() {
+		config := newConfig()
+		subConfig, _ := config.GetSubConfigWithMode("db", lib.WriteThrough)
+
+		var newValue interface{}
+		subConfig.OnChange("user", func(_ interface{}, value interface{}) { newValue = value })
+		config.Set("db:user", "admin")
+		So(newValue, ShouldEqual, "admin")
+	})
+
+	Convey("Returns an error if the sub-config can't be cast to a map", t, func() {
+		config := newConfig()
+		subConfig, err := config.GetSubConfigWithMode("db:host", lib.WriteThrough)
+		So(subConfig, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestIsolation(t *testing.T) {
+
+	Convey("Loading a map doesn't modify it or keep a reference to it", t, func() {
+		defaults := map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"port": 5432}}))
+		config.Use(lib.NewMapLoader(defaults))
+
+		So(defaults, ShouldResemble, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+
+		defaults["db"].(map[string]interface{})["host"] = "changed"
+		host, _ := config.GetString("db:host")
+		So(host, ShouldEqual, "localhost")
+	})
+
+	Convey("Modifying read maps and slices doesn't affect the configuration", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost"},
+			"hosts": []interface{}{"a", "b"},
+		}))
+
+		m, _ := config.GetMap("db")
+		m["host"] = "changed"
+		slice, _ := config.GetSlice("hosts")
+		slice[0] = "changed"
+		value, _ := config.Get("db")
+		value.(map[string]interface{})["host"] = "changed"
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"db":    map[string]interface{}{"host": "localhost"},
+			"hosts": []interface{}{"a", "b"},
+		})
+	})
+
+	Convey("Modifying typed maps and slices doesn't affect the configuration or the loaded map", t, func() {
+		defaults := map[string]interface{}{
+			"hosts":  []string{"a", "b"},
+			"labels": map[string]string{"team": "core"},
+		}
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(defaults))
+
+		hosts, _ := config.GetStringSlice("hosts")
+		hosts[0] = "X"
+		labels, _ := lib.GetAs[map[string]string](config, "labels")
+		labels["team"] = "X"
+		defaults["hosts"].([]string)[1] = "Y"
+
+		So(config.Snapshot(), ShouldResemble, map[string]interface{}{
+			"hosts":  []string{"a", "b"},
+			"labels": map[string]string{"team": "core"},
+		})
+		So(defaults["hosts"], ShouldResemble, []string{"a", "Y"})
+	})
+
+	Convey("Modifying a set value afterwards doesn't affect the configuration", t, func() {
+		config := lib.NewConfig()
+		value := map[string]interface{}{"host": "localhost"}
+		config.Set("db", value)
+		value["host"] = "changed"
+
+		host, _ := config.GetString("db:host")
+		So(host, ShouldEqual, "localhost")
+	})
+
+	Convey("Modifying a decoded structure doesn't affect the configuration", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(map[string]interface{}{"db": map[string]interface{}{"tags": []interface{}{"a"}}}))
+
+		structure := struct{ DB map[string]interface{} }{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		structure.DB["tags"].([]interface{})[0] = "changed"
+
+		tags, _ := config.GetStringSlice("db:tags")
+		So(tags, ShouldResemble, []string{"a"})
+	})
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+type change struct {
+	name     string
+	oldValue interface{}
+	newValue interface{}
+}
+
+func TestOnChange(t *testing.T) {
+
+	Convey("Subscriptions", t, func() {
+		loader := lib.NewMapLoader(map[string]interface{}{
+			"db":    map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a", "b"}},
+			"other": 1,
+		})
+		config := lib.NewConfig()
+		config.Use(loader)
+
+		changes := []change{}
+		record := func(name string) lib.ChangeHandler {
+			return func(oldValue interface{}, newValue interface{}) {
+				changes = append(changes, change{name, oldValue, newValue})
+			}
+		}
+
+		Convey("Fire when the value of the key changes", func() {
+			config.OnChange("db:maxConns", record("maxConns"))
+			loader.Map = map[string]interface{}{"db": map[string]interface{}{"maxConns": 20}}
+			So(config.Reload(), ShouldBeNil)
+			So(changes, ShouldResemble, []change{{"maxConns", 10, 20}})
+		})
+
+		Convey("Fire when a value nested under the key changes", func() {
+			config.OnChange("db", record("db"))
+			loader.Map = map[string]interface{}{"db": map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a", "c"}}}
+			So(config.Reload(), ShouldBeNil)
+			So(changes, ShouldResemble, []change{{
+				"db",
+				map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a", "b"}},
+				map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a", "c"}},
+			}})
+		})
+
+		Convey("Don't fire when nothing under the key changed", func() {
+			config.OnChange("db", record("db"))
+			loader.Map = map[string]interface{}{"db": map[string]interface{}{"maxConns": 10, "hosts": []interface{}{"a", "b"}}, "other": 2}
+			So(config.Reload(), ShouldBeNil)
+			So(changes, ShouldBeEmpty)
+		})
+
+		Convey("Pass nil for values that were added or removed", func() {
+			config.OnChange("db:maxConns", record("maxConns"))
+			config.OnChange("new", record("new"))
+			loader.Map = map[string]interface{}{"new": true}
+			So(config.Reload(), ShouldBeNil)
+			So(changes, ShouldResemble, []change{{"maxConns", 10, nil}, {"new", nil, true}})
+		})
+
+		Convey("Fire when a new loader is used", func() {
+			config.OnChange("added", record("added"))
+			config.Use(lib.NewMapLoader(map[string]interface{}{"added": "yes"}))
+			So(changes, ShouldResemble, []change{{"added", nil, "yes"}})
+		})
+
+		Convey("Fire in the order they were registered", func() {
+			config.OnChange("other", record("first"))
+			config.OnChange("", record("second"))
+			config.OnChange("other", record("third"))
+			loader.Map = map[string]interface{}{"other": 2}
+			So(config.Reload(), ShouldBeNil)
+			So(len(changes), ShouldEqual, 3)
+			So(changes[0].name, ShouldEqual, "first")
+			So(changes[1].name, ShouldEqual, "second")
+			So(changes[2].name, ShouldEqual, "third")
+		})
+
+		Convey("Don't fire when the reload fails", func() {
+			failing := &failingLoader{}
+			config.Use(failing)
+			config.OnChange("other", record("other"))
+			failing.fail = true
+			loader.Map = map[string]interface{}{"other": 2}
+			So(config.Reload(), ShouldNotBeNil)
+			So(changes, ShouldBeEmpty)
+		})
+
+		Convey("Can be removed", func() {
+			unsubscribe := config.OnChange("other", record("other"))
+			unsubscribe()
+			loader.Map = map[string]interface{}{"other": 2}
+			So(config.Reload(), ShouldBeNil)
+			So(changes, ShouldBeEmpty)
+		})
+
+		Convey("Can read the configuration from the handler", func() {
+			var value int
+			config.OnChange("other", func(oldValue interface{}, newValue interface{}) {
+				value, _ = config.GetInteger("other")
+			})
+			loader.Map = map[string]interface{}{"other": 2}
+			So(config.Reload(), ShouldBeNil)
+			So(value, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestTOMLFileLoad(t *testing.T) {
+
+	Convey("Returns an error when the file can't be found", t, func() {
+		result, err := lib.NewTOMLFileLoader("").Load()
+		So(result, ShouldBeEmpty)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Loads a TOML file", t, func() {
+		result, err := lib.NewTOMLFileLoader("test.toml").Load()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, map[string]interface{}{
+			"string":   "woohoo",
+			"boolean":  true,
+			"integer":  10,
+			"float":    3.5,
+			"datetime": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+			"array":    []interface{}{"woohoo", "yay"},
+			"object": map[string]interface{}{
+				"string":  "woohoo",
+				"integer": 10,
+			},
+			"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 1},
+				map[string]interface{}{"host": "b", "port": 2},
+			},
+		})
+	})
+
+	Convey("Loads a TOML file that can be read without further conversion", t, func() {
+		config := lib.NewConfig()
+		config.Use(lib.NewTOMLFileLoader("test.toml"))
+
+		integer, err := config.GetInteger("object:integer")
+		So(integer, ShouldEqual, 10)
+		So(err, ShouldBeNil)
+
+		subConfig, err := config.GetSubConfig("object")
+		So(subConfig.Snapshot(), ShouldResemble, map[string]interface{}{"string": "woohoo", "integer": 10})
+		So(err, ShouldBeNil)
+
+		structure := struct {
+			Integer  int
+			Datetime time.Time
+			Servers  []struct {
+				Host string
+				Port int
+			}
+		}{}
+		err = config.ToStructure(&structure)
+		So(err, ShouldBeNil)
+		So(structure.Integer, ShouldEqual, 10)
+		So(structure.Datetime, ShouldEqual, time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC))
+		So(len(structure.Servers), ShouldEqual, 2)
+		So(structure.Servers[1].Host, ShouldEqual, "b")
+		So(structure.Servers[1].Port, ShouldEqual, 2)
+	})
+}
+
+func TestParseTOML(t *testing.T) {
+	loader := lib.NewTOMLFileLoader("")
+
+	Convey("Returns an error when parsing invalid TOML", t, func() {
+		result, err := loader.ParseTOML([]byte("a = "))
+		So(result, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Parses nested tables into maps", t, func() {
+		result, err := loader.ParseTOML([]byte("[a.b]\nc = 1"))
+		So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Parses integers inside arrays into ints", t, func() {
+		result, err := loader.ParseTOML([]byte("a = [1, 2]"))
+		So(result, ShouldResemble, map[string]interface{}{"a": []interface{}{1, 2}})
+		So(err, ShouldBeNil)
+	})
+}
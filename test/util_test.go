@@ -1,6 +1,7 @@
 package test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -87,6 +88,153 @@ func TestGet(t *testing.T) {
 	})
 }
 
+func TestSliceIndexes(t *testing.T) {
+	newMap := func() map[string]interface{} {
+		return map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"host": "a"}, "b"},
+		}
+	}
+
+	Convey("Get indexes into slices", t, func() {
+		result, err := lib.Get(newMap(), []string{"servers", "0", "host"})
+		So(result, ShouldEqual, "a")
+		So(err, ShouldBeNil)
+
+		result, err = lib.Get(newMap(), []string{"servers", "1"})
+		So(result, ShouldEqual, "b")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Get returns an error for indexes that are out of range or not numeric", t, func() {
+		for _, key := range []string{"2", "-1", "host"} {
+			_, err := lib.Get(newMap(), []string{"servers", key})
+			So(errors.Is(err, lib.ErrNotFound), ShouldBeTrue)
+		}
+	})
+
+	Convey("Set sets keys inside slice elements and appends to slices", t, func() {
+		result, err := lib.Set(newMap(), []string{"servers", "0", "port"}, 80)
+		So(err, ShouldBeNil)
+		result, err = lib.Set(result, []string{"servers", "2"}, "c")
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"host": "a", "port": 80}, "b", "c"},
+		})
+	})
+
+	Convey("Set returns an error when an element is already present", t, func() {
+		_, err := lib.Set(newMap(), []string{"servers", "1"}, "c")
+		So(errors.Is(err, lib.ErrConflict), ShouldBeTrue)
+	})
+
+	Convey("Set and Replace return an error for keys that don't index into a slice, leaving it in place", t, func() {
+		m := newMap()
+		_, err := lib.Set(m, []string{"servers", "5", "host"}, "x")
+		var rangeError *lib.RangeError
+		So(errors.As(err, &rangeError), ShouldBeTrue)
+		So(rangeError.Key, ShouldEqual, "servers:5")
+		So(err.Error(), ShouldEqual, "key 'servers:5': index is out of range for a slice of length 2")
+
+		_, err = lib.Set(m, []string{"servers", "-1"}, "x")
+		So(errors.As(err, &rangeError), ShouldBeTrue)
+
+		_, err = lib.Set(m, []string{"servers", "host"}, "x")
+		var typeError *lib.TypeError
+		So(errors.As(err, &typeError), ShouldBeTrue)
+		So(typeError.Key, ShouldEqual, "servers")
+
+		lib.Replace(m, []string{"servers", "5", "host"}, "x")
+		So(m, ShouldResemble, newMap())
+	})
+
+	Convey("Replace overwrites slice elements", t, func() {
+		result := lib.Replace(newMap(), []string{"servers", "1"}, "c")
+		So(result, ShouldResemble, map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"host": "a"}, "c"},
+		})
+	})
+
+	Convey("Delete removes slice elements", t, func() {
+		result, err := lib.Delete(newMap(), []string{"servers", "0"})
+		So(result, ShouldResemble, map[string]interface{}{"servers": []interface{}{"b"}})
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestSplitKey(t *testing.T) {
+
+	Convey("Splits a key on colons", t, func() {
+		So(lib.SplitKey("a:b:c"), ShouldResemble, []string{"a", "b", "c"})
+	})
+
+	Convey("Splits a key on the supplied delimiter", t, func() {
+		So(lib.SplitKeyWith("a.b::c", "."), ShouldResemble, []string{"a", "b::c"})
+		So(lib.SplitKeyWith("a::b:c", "::"), ShouldResemble, []string{"a", "b:c"})
+	})
+
+	Convey("Keeps escaped delimiters and backslashes in the keys", t, func() {
+		So(lib.SplitKey(`urn\:x:y`), ShouldResemble, []string{"urn:x", "y"})
+		So(lib.SplitKey(`a\\:b`), ShouldResemble, []string{`a\`, "b"})
+		So(lib.SplitKey(`a\b:c\`), ShouldResemble, []string{`a\b`, `c\`})
+		So(lib.SplitKeyWith(`a\.b.c`, "."), ShouldResemble, []string{"a.b", "c"})
+	})
+
+	Convey("Escapes delimiters and backslashes when joining keys", t, func() {
+		keys := []string{"urn:x", `a\`, "b"}
+		So(lib.JoinKey(keys), ShouldEqual, `urn\:x:a\\:b`)
+		So(lib.SplitKey(lib.JoinKey(keys)), ShouldResemble, keys)
+		So(lib.JoinKeyWith([]string{"a.b", "c"}, "."), ShouldEqual, `a\.b.c`)
+	})
+}
+
+func TestReplace(t *testing.T) {
+
+	Convey("Returns the input map when no keys are specified", t, func() {
+		result := lib.Replace(map[string]interface{}{}, []string{}, nil)
+		So(result, ShouldBeEmpty)
+	})
+
+	Convey("Sets a nested key to the specified value", t, func() {
+		result := lib.Replace(map[string]interface{}{}, []string{"a", "b"}, "testing")
+		So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": "testing"}})
+	})
+
+	Convey("Overwrites a key that is already present", t, func() {
+		result := lib.Replace(map[string]interface{}{"a": map[string]interface{}{"b": true, "c": true}}, []string{"a", "b"}, false)
+		So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": false, "c": true}})
+	})
+
+	Convey("Replaces parent keys that aren't maps", t, func() {
+		result := lib.Replace(map[string]interface{}{"a": true}, []string{"a", "b"}, "testing")
+		So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": "testing"}})
+	})
+}
+
+func TestDelete(t *testing.T) {
+
+	Convey("Deletes a nested key", t, func() {
+		result, err := lib.Delete(map[string]interface{}{"a": map[string]interface{}{"b": true, "c": true}}, []string{"a", "b"})
+		So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"c": true}})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Deletes everything under a key", t, func() {
+		result, err := lib.Delete(map[string]interface{}{"a": map[string]interface{}{"b": true}, "c": true}, []string{"a"})
+		So(result, ShouldResemble, map[string]interface{}{"c": true})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns an error when the key doesn't exist", t, func() {
+		_, err := lib.Delete(map[string]interface{}{"a": map[string]interface{}{}}, []string{"a", "b"})
+		So(errors.Is(err, lib.ErrNotFound), ShouldBeTrue)
+	})
+
+	Convey("Returns an error when a parent key isn't a map", t, func() {
+		_, err := lib.Delete(map[string]interface{}{"a": true}, []string{"a", "b"})
+		So(errors.Is(err, lib.ErrTypeMismatch), ShouldBeTrue)
+	})
+}
+
 func TestMerge(t *testing.T) {
 
 	Convey("Merges non-nested keys", t, func() {
@@ -110,6 +258,27 @@ func TestMerge(t *testing.T) {
 	})
 }
 
+func TestDeepCopy(t *testing.T) {
+
+	Convey("Returns nil for a nil map", t, func() {
+		So(lib.DeepCopy(nil), ShouldBeNil)
+	})
+
+	Convey("Copies nested maps and slices", t, func() {
+		original := map[string]interface{}{
+			"map":   map[string]interface{}{"one": 1},
+			"slice": []interface{}{map[string]interface{}{"two": 2}},
+		}
+		result := lib.DeepCopy(original)
+		So(result, ShouldResemble, original)
+
+		result["map"].(map[string]interface{})["one"] = 10
+		result["slice"].([]interface{})[0].(map[string]interface{})["two"] = 20
+		So(original["map"], ShouldResemble, map[string]interface{}{"one": 1})
+		So(original["slice"], ShouldResemble, []interface{}{map[string]interface{}{"two": 2}})
+	})
+}
+
 func TestParseString(t *testing.T) {
 
 	Convey("Parses booleans", t, func() {
@@ -217,6 +386,18 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Casts a generic slice element by element", func() {
+			result, err := lib.CastStringSlice([]interface{}{"one", "two"})
+			So(result, ShouldResemble, []string{"one", "two"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Names the element that couldn't be cast", func() {
+			result, err := lib.CastStringSlice([]interface{}{"one", 2})
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "failed to cast value to string slice: element 1 has type int")
+		})
+
 		Convey("Returns an error if the cast can't be done", func() {
 			result, err := lib.CastStringSlice(5)
 			So(result, ShouldBeNil)
@@ -253,6 +434,18 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Casts a generic slice element by element", func() {
+			result, err := lib.CastIntegerSlice([]interface{}{1, float64(2)})
+			So(result, ShouldResemble, []int{1, 2})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Names the element that couldn't be cast", func() {
+			result, err := lib.CastIntegerSlice([]interface{}{1, 2.5})
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "failed to cast value to integer slice: element 1 has type float64")
+		})
+
 		Convey("Returns an error when casting a float slice to an int slice would truncate a value", func() {
 			result, err := lib.CastIntegerSlice([]float64{1.5, 2})
 			So(result, ShouldBeNil)
@@ -280,6 +473,12 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Casts a 64 bit integer to an int", func() {
+			result, err := lib.CastInteger(int64(1))
+			So(result, ShouldEqual, 1)
+			So(err, ShouldBeNil)
+		})
+
 		Convey("Returns an error when a converting to a int would truncate the value", func() {
 			result, err := lib.CastInteger(1.5)
 			So(result, ShouldBeZeroValue)
@@ -293,6 +492,40 @@ func TestCasts(t *testing.T) {
 		})
 	})
 
+	Convey("CastInteger64", t, func() {
+
+		Convey("Casts integers and integral floats into a 64 bit integer", func() {
+			result, err := lib.CastInteger64(1)
+			So(result, ShouldEqual, int64(1))
+			So(err, ShouldBeNil)
+
+			result, err = lib.CastInteger64(float64(2))
+			So(result, ShouldEqual, int64(2))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastInteger64(1.5)
+			So(result, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastUnsignedInteger", t, func() {
+
+		Convey("Casts a non-negative integer into an unsigned integer", func() {
+			result, err := lib.CastUnsignedInteger(1)
+			So(result, ShouldEqual, uint(1))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error for negative values", func() {
+			result, err := lib.CastUnsignedInteger(-1)
+			So(result, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
 	Convey("CastBooleanSlice", t, func() {
 
 		Convey("Casts an empty interface into a boolean slice", func() {
@@ -301,6 +534,18 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Casts a generic slice element by element", func() {
+			result, err := lib.CastBooleanSlice([]interface{}{true, false})
+			So(result, ShouldResemble, []bool{true, false})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Names the element that couldn't be cast", func() {
+			result, err := lib.CastBooleanSlice([]interface{}{"true"})
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "failed to cast value to boolean slice: element 0 has type string")
+		})
+
 		Convey("Returns an error if the cast can't be done", func() {
 			result, err := lib.CastBooleanSlice(5)
 			So(result, ShouldBeNil)
@@ -331,6 +576,18 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Casts a generic slice element by element", func() {
+			result, err := lib.CastFloatSlice([]interface{}{1.5, 2.5})
+			So(result, ShouldResemble, []float64{1.5, 2.5})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Names the element that couldn't be cast", func() {
+			result, err := lib.CastFloatSlice([]interface{}{1.5, nil})
+			So(result, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "failed to cast value to float slice: element 1 has type <nil>")
+		})
+
 		Convey("Returns an error if the cast can't be done", func() {
 			result, err := lib.CastFloatSlice(5)
 			So(result, ShouldBeNil)
@@ -346,10 +603,134 @@ func TestCasts(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Converts integers into a float", func() {
+			result, err := lib.CastFloat(3)
+			So(result, ShouldEqual, 3.0)
+			So(err, ShouldBeNil)
+
+			result, err = lib.CastFloat(int64(4))
+			So(result, ShouldEqual, 4.0)
+			So(err, ShouldBeNil)
+		})
+
 		Convey("Returns an error if the cast can't be done", func() {
 			result, err := lib.CastFloat("Hello")
 			So(result, ShouldBeZeroValue)
 			So(err, ShouldNotBeNil)
 		})
 	})
+
+	Convey("CastDuration", t, func() {
+
+		Convey("Casts durations and duration strings into a duration", func() {
+			result, err := lib.CastDuration(3 * time.Second)
+			So(result, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+
+			result, err = lib.CastDuration("3s")
+			So(result, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Casts numbers of seconds into a duration", func() {
+			result, err := lib.CastDuration(3)
+			So(result, ShouldEqual, 3*time.Second)
+			So(err, ShouldBeNil)
+
+			result, err = lib.CastDuration(1.5)
+			So(result, ShouldEqual, 1500*time.Millisecond)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastDuration("Hello")
+			So(result, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastDurationSlice", t, func() {
+
+		Convey("Casts a generic slice into a duration slice", func() {
+			result, err := lib.CastDurationSlice([]interface{}{"1s", 2, 3 * time.Second})
+			So(result, ShouldResemble, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastDurationSlice([]interface{}{"Hello"})
+			So(result, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastTimeSlice", t, func() {
+
+		Convey("Casts a generic slice into a time slice", func() {
+			result, err := lib.CastTimeSlice([]interface{}{"2018-05-27T07:32:00Z"})
+			So(result, ShouldResemble, []time.Time{time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC)})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastTimeSlice(5)
+			So(result, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastTime", t, func() {
+
+		Convey("Casts RFC3339 strings into a time", func() {
+			result, err := lib.CastTime("2018-05-27T07:32:00Z")
+			So(result, ShouldEqual, time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Casts numbers of seconds since the Unix epoch into a time", func() {
+			result, err := lib.CastTime(1527406320)
+			So(result.Equal(time.Date(2018, 5, 27, 7, 32, 0, 0, time.UTC)), ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			result, err = lib.CastTime(1527406320.5)
+			So(result.Equal(time.Date(2018, 5, 27, 7, 32, 0, int(500*time.Millisecond), time.UTC)), ShouldBeTrue)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastTime("Hello")
+			So(result, ShouldBeZeroValue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastURL", t, func() {
+
+		Convey("Casts a string into a URL", func() {
+			result, err := lib.CastURL("https://example.com")
+			So(result.Host, ShouldEqual, "example.com")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if the cast can't be done", func() {
+			result, err := lib.CastURL(5)
+			So(result, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CastStringMap", t, func() {
+
+		Convey("Casts a map with string values into a string map", func() {
+			result, err := lib.CastStringMap(map[string]interface{}{"a": "b"})
+			So(result, ShouldResemble, map[string]string{"a": "b"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns an error if a value isn't a string", func() {
+			result, err := lib.CastStringMap(map[string]interface{}{"a": 1})
+			So(result, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+	})
 }
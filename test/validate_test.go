@@ -0,0 +1,131 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+type validatedPort int
+
+type validatedServer struct {
+	Host string        `mapstructure:"host" validate:"required"`
+	Port validatedPort `mapstructure:"port"`
+}
+
+type validatedDatabase struct {
+	URL     string        `mapstructure:"url" validate:"required" pattern:"^postgres://"`
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=1s,max=1m"`
+}
+
+type validatedTLS struct {
+	Cert string `mapstructure:"cert" validate:"required"`
+}
+
+type validatedConfig struct {
+	Database validatedDatabase `mapstructure:"db"`
+	Mode     string            `mapstructure:"mode" validate:"enum=dev|prod"`
+	Workers  int               `mapstructure:"workers" validate:"min=1,max=64"`
+	Tags     []string          `mapstructure:"tags" validate:"max=2"`
+	Servers  []validatedServer `mapstructure:"servers"`
+	TLS      *validatedTLS     `mapstructure:"tls"`
+}
+
+func TestValidation(t *testing.T) {
+	lib.RegisterValidator(func(port validatedPort) error {
+		if port == 0 || port > 65535 {
+			return fmt.Errorf("must be a valid port, not %d", port)
+		}
+		return nil
+	})
+
+	newValidatedConfig := func(m map[string]interface{}) *lib.Config {
+		config := lib.NewConfig()
+		config.Use(lib.NewMapLoader(m))
+		return config
+	}
+
+	Convey("Decodes structures that follow every rule", t, func() {
+		config := newValidatedConfig(map[string]interface{}{
+			"db":      map[string]interface{}{"url": "postgres://db", "timeout": 5 * time.Second},
+			"mode":    "prod",
+			"workers": 8,
+			"servers": []interface{}{map[string]interface{}{"host": "a", "port": 80}},
+		})
+		structure := validatedConfig{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.Database.URL, ShouldEqual, "postgres://db")
+	})
+
+	Convey("Reports missing required keys, even inside missing structures", t, func() {
+		structure := validatedConfig{}
+		err := newValidatedConfig(map[string]interface{}{}).ToStructure(&structure)
+		So(errors.Is(err, lib.ErrInvalid), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "validation failed: key 'db:url' is required")
+	})
+
+	Convey("Aggregates every violation with its key", t, func() {
+		config := newValidatedConfig(map[string]interface{}{
+			"db":      map[string]interface{}{"url": "mysql://db", "timeout": 5 * time.Minute},
+			"mode":    "test",
+			"workers": 0,
+			"tags":    []interface{}{"a", "b", "c"},
+			"servers": []interface{}{
+				map[string]interface{}{"host": "a", "port": 80},
+				map[string]interface{}{"port": 70000},
+			},
+		})
+		err := config.ToStructure(&validatedConfig{})
+
+		var validationError *lib.ValidationError
+		So(errors.As(err, &validationError), ShouldBeTrue)
+		So(validationError.Violations, ShouldResemble, []lib.Violation{
+			{Key: "db:url", Rule: "pattern", Message: "must match the pattern '^postgres://'"},
+			{Key: "db:timeout", Rule: "max", Message: "must be at most 1m"},
+			{Key: "mode", Rule: "enum", Message: "must be one of dev, prod"},
+			{Key: "workers", Rule: "min", Message: "must be at least 1"},
+			{Key: "tags", Rule: "max", Message: "must have a length of at most 2"},
+			{Key: "servers:1:host", Rule: "required", Message: "is required"},
+			{Key: "servers:1:port", Rule: "test.validatedPort", Message: "must be a valid port, not 70000"},
+		})
+	})
+
+	Convey("Only validates keys that are present", t, func() {
+		config := newValidatedConfig(map[string]interface{}{"db": map[string]interface{}{"url": "postgres://db"}})
+		So(config.ToStructure(&validatedConfig{}), ShouldBeNil)
+	})
+
+	Convey("Only requires the fields of optional sections when they are present", t, func() {
+		config := newValidatedConfig(map[string]interface{}{"db": map[string]interface{}{"url": "postgres://db"}})
+		structure := validatedConfig{}
+		So(config.ToStructure(&structure), ShouldBeNil)
+		So(structure.TLS, ShouldBeNil)
+
+		config = newValidatedConfig(map[string]interface{}{
+			"db":  map[string]interface{}{"url": "postgres://db"},
+			"tls": map[string]interface{}{},
+		})
+		So(config.ToStructure(&validatedConfig{}).Error(), ShouldEqual, "validation failed: key 'tls:cert' is required")
+	})
+
+	Convey("Requires the fields of sections that are required themselves", t, func() {
+		config := newValidatedConfig(map[string]interface{}{})
+		err := config.ToStructure(&struct {
+			TLS *validatedTLS `mapstructure:"tls" validate:"required"`
+		}{})
+		So(err.Error(), ShouldEqual, "validation failed: key 'tls' is required; key 'tls:cert' is required")
+	})
+
+	Convey("Returns an error for malformed rules", t, func() {
+		config := newValidatedConfig(map[string]interface{}{"port": 80})
+		err := config.ToStructure(&struct {
+			Port int `mapstructure:"port" validate:"between=1|2"`
+		}{})
+		So(err.Error(), ShouldEqual, "key 'port': invalid validation rule 'between=1|2': unknown rule")
+		So(errors.Is(err, lib.ErrInvalid), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+// eventually polls the supplied condition until it is true or a timeout is hit
+func eventually(condition func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestWatch(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		options := lib.WatchOptions{Debounce: 10 * time.Millisecond, PollInterval: 10 * time.Millisecond, Poll: poll}
+
+		Convey("Reloads the configuration when a watched file changes", t, func() {
+			directory, err := ioutil.TempDir("", "gconf")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(directory)
+			path := filepath.Join(directory, "config.json")
+			So(ioutil.WriteFile(path, []byte(`{"a": "before"}`), 0644), ShouldBeNil)
+
+			config := lib.NewConfig()
+			So(config.Use(lib.NewJSONFileLoader(path, false)), ShouldBeNil)
+			watcher, err := config.Watch(options)
+			So(err, ShouldBeNil)
+			defer watcher.Close()
+
+			Convey("When the file is written to", func() {
+				So(ioutil.WriteFile(path, []byte(`{"a": "after"}`), 0644), ShouldBeNil)
+				So(eventually(func() bool { value, _ := config.GetString("a"); return value == "after" }), ShouldBeTrue)
+			})
+
+			Convey("When the file is replaced by a rename", func() {
+				temporaryPath := filepath.Join(directory, "config.json.tmp")
+				So(ioutil.WriteFile(temporaryPath, []byte(`{"a": "renamed"}`), 0644), ShouldBeNil)
+				So(os.Rename(temporaryPath, path), ShouldBeNil)
+				So(eventually(func() bool { value, _ := config.GetString("a"); return value == "renamed" }), ShouldBeTrue)
+			})
+		})
+
+		Convey("Watches the files of layers added after it started", t, func() {
+			directory, err := ioutil.TempDir("", "gconf")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(directory)
+			path := filepath.Join(directory, "config.json")
+			So(ioutil.WriteFile(path, []byte(`{"a": "before"}`), 0644), ShouldBeNil)
+
+			config := lib.NewConfig()
+			watcher, err := config.Watch(options)
+			So(err, ShouldBeNil)
+			defer watcher.Close()
+
+			So(config.UseLayer("file", 10, lib.NewJSONFileLoader(path, false)), ShouldBeNil)
+			So(ioutil.WriteFile(path, []byte(`{"a": "after"}`), 0644), ShouldBeNil)
+			So(eventually(func() bool { value, _ := config.GetString("a"); return value == "after" }), ShouldBeTrue)
+		})
+
+		if !poll {
+			Convey("Reloads the configuration when a file is rewritten without changing its size or modification time", t, func() {
+				directory, err := ioutil.TempDir("", "gconf")
+				So(err, ShouldBeNil)
+				defer os.RemoveAll(directory)
+				path := filepath.Join(directory, "config.json")
+				So(ioutil.WriteFile(path, []byte(`{"a": "one"}`), 0644), ShouldBeNil)
+				info, err := os.Stat(path)
+				So(err, ShouldBeNil)
+
+				config := lib.NewConfig()
+				So(config.Use(lib.NewJSONFileLoader(path, false)), ShouldBeNil)
+				watcher, err := config.Watch(lib.WatchOptions{Debounce: 200 * time.Millisecond})
+				So(err, ShouldBeNil)
+				defer watcher.Close()
+
+				So(ioutil.WriteFile(path, []byte(`{"a": "two"}`), 0644), ShouldBeNil)
+				So(os.Chtimes(path, info.ModTime(), info.ModTime()), ShouldBeNil)
+				So(eventually(func() bool { value, _ := config.GetString("a"); return value == "two" }), ShouldBeTrue)
+			})
+		}
+
+		Convey("Reloads the configuration when a symlinked directory is swapped", t, func() {
+			directory, err := ioutil.TempDir("", "gconf")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(directory)
+
+			// Mimic the layout of a mounted Kubernetes ConfigMap
+			So(os.Mkdir(filepath.Join(directory, "v1"), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(directory, "v1", "config.json"), []byte(`{"a": "v1"}`), 0644), ShouldBeNil)
+			So(os.Symlink("v1", filepath.Join(directory, "..data")), ShouldBeNil)
+			So(os.Symlink(filepath.Join("..data", "config.json"), filepath.Join(directory, "config.json")), ShouldBeNil)
+
+			config := lib.NewConfig()
+			So(config.Use(lib.NewJSONFileLoader(filepath.Join(directory, "config.json"), false)), ShouldBeNil)
+			watcher, err := config.Watch(options)
+			So(err, ShouldBeNil)
+			defer watcher.Close()
+
+			So(os.Mkdir(filepath.Join(directory, "v2"), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(directory, "v2", "config.json"), []byte(`{"a": "v2"}`), 0644), ShouldBeNil)
+			So(os.Symlink("v2", filepath.Join(directory, "..data_tmp")), ShouldBeNil)
+			So(os.Rename(filepath.Join(directory, "..data_tmp"), filepath.Join(directory, "..data")), ShouldBeNil)
+			So(eventually(func() bool { value, _ := config.GetString("a"); return value == "v2" }), ShouldBeTrue)
+		})
+
+		Convey("Reports reload errors and keeps the old configuration", t, func() {
+			directory, err := ioutil.TempDir("", "gconf")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(directory)
+			path := filepath.Join(directory, "config.json")
+			So(ioutil.WriteFile(path, []byte(`{"a": "before"}`), 0644), ShouldBeNil)
+
+			config := lib.NewConfig()
+			So(config.Use(lib.NewJSONFileLoader(path, false)), ShouldBeNil)
+			errors := make(chan error, 10)
+			options.OnError = func(err error) { errors <- err }
+			watcher, err := config.Watch(options)
+			So(err, ShouldBeNil)
+			defer watcher.Close()
+
+			So(ioutil.WriteFile(path, []byte(`{"a": `), 0644), ShouldBeNil)
+			select {
+			case err := <-errors:
+				So(err, ShouldNotBeNil)
+			case <-time.After(5 * time.Second):
+				t.Error("timed out waiting for an error")
+			}
+			value, err := config.GetString("a")
+			So(value, ShouldEqual, "before")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Can be closed more than once", t, func() {
+			watcher, err := lib.NewWatcher(lib.NewConfig(), []string{"test.json"}, options)
+			So(err, ShouldBeNil)
+			So(watcher.Close(), ShouldBeNil)
+			So(watcher.Close(), ShouldBeNil)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/thalmic/gconf/lib"
+)
+
+func TestYAMLFileLoad(t *testing.T) {
+
+	Convey("Returns an error when the file can't be found", t, func() {
+		result, err := lib.NewYAMLFileLoader("", false).Load()
+		So(result, ShouldBeEmpty)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Loads a YAML file", t, func() {
+		result, err := lib.NewYAMLFileLoader("test.yaml", false).Load()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, map[string]interface{}{
+			"string":  "woohoo",
+			"boolean": true,
+			"integer": 10,
+			"float":   3.5,
+			"array":   []interface{}{"woohoo", true, 10, 3.5},
+			"object": map[string]interface{}{
+				"string":  "woohoo",
+				"boolean": true,
+				"integer": 10,
+				"float":   3.5,
+			},
+		})
+	})
+}
+
+func TestParseYAML(t *testing.T) {
+	loader := lib.NewYAMLFileLoader("", false)
+
+	Convey("Returns an error when parsing invalid YAML", t, func() {
+		result, err := loader.ParseYAML([]byte("a: [b"))
+		So(result, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error when the document isn't a mapping", t, func() {
+		result, err := loader.ParseYAML([]byte("- a\n- b"))
+		So(result, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Parses an empty document into an empty map", t, func() {
+		result, err := loader.ParseYAML([]byte(""))
+		So(result, ShouldBeEmpty)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Parses valid YAML into a map", t, func() {
+
+		Convey("Parses YAML without sub objects", func() {
+			result, err := loader.ParseYAML([]byte("a: b"))
+			So(result, ShouldResemble, map[string]interface{}{"a": "b"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Converts sub objects to string keyed maps", func() {
+			result, err := loader.ParseYAML([]byte("a:\n  b: c\n  1: d"))
+			So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": "c", "1": "d"}})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Converts objects nested in arrays to string keyed maps", func() {
+			result, err := loader.ParseYAML([]byte("a:\n  - b: c"))
+			So(result, ShouldResemble, map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": "c"}}})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns the original map when duration parsing is disabled", func() {
+			result, err := loader.ParseYAML([]byte("a: 3s"))
+			So(result, ShouldResemble, map[string]interface{}{"a": "3s"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Returns a modified map when duration parsing is enabled", func() {
+			l := lib.NewYAMLFileLoader("", true)
+			result, err := l.ParseYAML([]byte("a:\n  b: 3s"))
+			So(result, ShouldResemble, map[string]interface{}{"a": map[string]interface{}{"b": 3 * time.Second}})
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Parses multiple documents", t, func() {
+		data := []byte("a: 1\nb: 2\n---\nb: 3\nc: 4")
+
+		Convey("Only reads the first document by default", func() {
+			result, err := loader.ParseYAML(data)
+			So(result, ShouldResemble, map[string]interface{}{"a": 1, "b": 2})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Merges every document when enabled, with earlier documents winning", func() {
+			l := lib.NewYAMLFileLoader("", false)
+			l.MultiDocument = true
+			result, err := l.ParseYAML(data)
+			So(result, ShouldResemble, map[string]interface{}{"a": 1, "b": 2, "c": 4})
+			So(err, ShouldBeNil)
+		})
+	})
+}